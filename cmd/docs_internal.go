@@ -0,0 +1,12 @@
+package main
+
+// General info of the internal API docs, see go:generate in main.go
+
+// @title Profile internal API
+// @version 1.0
+// @description Service-to-service requests, protected by basic auth.
+
+// @host localhost:8030
+// @BasePath /internal/v1
+
+// @securityDefinitions.basic BasicAuth
@@ -1,13 +1,19 @@
-//go:generate swag init -parseDependency -d ../internal/api -g ../../cmd/main.go
+// public operations are listed by their tags, swag v1.8 can't only exclude the internal ones
+//go:generate swag init -parseDependency -d ../internal/api -g ../../cmd/main.go --tags 2fa,anti-phishing,auth,password,profile,registration,sessions,verification
+//go:generate swag init -parseDependency -d ../internal/api -g ../../cmd/docs_internal.go --tags internal --instanceName internal
+
 package main
 
 import (
+	"context"
 	golog "log"
-	"runtime"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/lib/pq" // postgres driver
 
+	_ "github.com/levongh/profile/cmd/docs" // swagger specs served by /swagger and /internal/swagger
 	"github.com/levongh/profile/internal/api"
 	"github.com/levongh/profile/internal/config"
 	"github.com/levongh/profile/internal/log"
@@ -33,6 +39,13 @@ func main() {
 	if err != nil {
 		golog.Fatal(err)
 	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			golog.Fatal(err)
+		}
+		return
+	}
 	// cfg.HostWithoutProtocol()
 	// change swagger host per deployment, see HOST env var
 	// swaggerSettings.SwaggerInfo.Host = cfg.HostWithoutProtocol()
@@ -40,32 +53,53 @@ func main() {
 	// Register database option data
 	// columns.RegisterOptionData()
 
-	logger, err := log.NewLogger(cfg.Mode, cfg.LogLevel)
+	os.Exit(run(cfg))
+}
 
+// run serves until SIGINT or SIGTERM and returns the exit code
+func run(cfg *config.Config) int {
+	logger, err := log.NewLogger(cfg.ServiceName, cfg.LogLevel,
+		log.WithSentry(cfg.SentryDSN, nil), log.WithSentryEnvironment(cfg.Mode))
 	if err != nil {
-		golog.Fatal(err)
+		golog.Print(err)
+		return 1
 	}
 
 	s, err := api.NewServer(cfg, logger)
 	if err != nil {
-		golog.Fatalf("can't start server: %s", err)
+		golog.Printf("can't start server: %s", err)
+		return 1
 	}
 
-	defer func() {
-		// Close jeafer tracer after server stopped.
-		err := s.Close()
-		if err != nil {
-			logger.Error(err.Error())
-		}
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// TODO: greaceful stutdown
+	serveErr := make(chan error, 1)
 	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			s.Logger.Debugf("Number of goroutines: %d", runtime.NumGoroutine())
-		}
+		serveErr <- s.Start(cfg.Port)
 	}()
 
-	golog.Fatal(s.Start(cfg.Port))
+	code := 0
+	select {
+	case err := <-serveErr:
+		logger.Error("server stopped", log.Error(err))
+		code = 1
+	case <-ctx.Done():
+		// the second signal kills the process without waiting for requests
+		stop()
+		logger.Info("shutting down")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDelay+cfg.ShutdownTimeout)
+		defer cancel()
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to drain requests", log.Error(err))
+			code = 1
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		logger.Error(err.Error())
+		code = 1
+	}
+	return code
 }
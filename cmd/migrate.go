@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/levongh/profile/internal/config"
+	"github.com/levongh/profile/internal/migration"
+)
+
+const migrateUsage = "usage: profile migrate up|down [steps]|status"
+
+// migrateCommand is parsed arguments of `profile migrate`
+type migrateCommand struct {
+	action string
+	steps  int
+}
+
+// parseMigrateArgs checks the arguments before the storage is connected
+func parseMigrateArgs(args []string) (migrateCommand, error) {
+	if len(args) == 0 {
+		return migrateCommand{}, errors.New(migrateUsage)
+	}
+
+	cmd := migrateCommand{action: args[0]}
+	switch cmd.action {
+	case "up", "status":
+		if len(args) > 1 {
+			return migrateCommand{}, errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return migrateCommand{}, errors.New(migrateUsage)
+		}
+		cmd.steps = 1
+		if len(args) > 1 {
+			steps, err := strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return migrateCommand{}, fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			cmd.steps = steps
+		}
+	default:
+		return migrateCommand{}, errors.New(migrateUsage)
+	}
+	return cmd, nil
+}
+
+// runMigrate handles `profile migrate` subcommand using migrations embedded into the binary
+func runMigrate(cfg *config.Config, args []string) error {
+	cmd, err := parseMigrateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := migration.New(cfg.StorageDSN)
+	if err != nil {
+		return err
+	}
+	defer m.Close() // nolint:errcheck
+
+	switch cmd.action {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down(cmd.steps)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Println(status)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMigrateArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		expected migrateCommand
+		wantErr  bool
+	}{
+		{name: "up", args: []string{"up"}, expected: migrateCommand{action: "up"}},
+		{name: "status", args: []string{"status"}, expected: migrateCommand{action: "status"}},
+		{name: "down one step by default", args: []string{"down"}, expected: migrateCommand{action: "down", steps: 1}},
+		{name: "down steps", args: []string{"down", "3"}, expected: migrateCommand{action: "down", steps: 3}},
+		{name: "no action", wantErr: true},
+		{name: "unknown action", args: []string{"drop"}, wantErr: true},
+		{name: "malformed steps", args: []string{"down", "all"}, wantErr: true},
+		{name: "zero steps", args: []string{"down", "0"}, wantErr: true},
+		{name: "extra args", args: []string{"up", "2"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := parseMigrateArgs(tc.args)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cmd)
+		})
+	}
+}
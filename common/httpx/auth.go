@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+// errNoIdentity is returned by IdentitySource if the request doesn't carry its credentials,
+// so the next source is tried
+var errNoIdentity = errors.New("no identity")
+
+// IdentitySource resolves the user the request is made on behalf of
+type IdentitySource interface {
+	// Identify returns user id and access token if the request has them,
+	// errNoIdentity if it carries no credentials of this source
+	Identify(r *http.Request) (userID, token string, err error)
+}
+
+// TokenVerifier validates access token and returns its subject
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (userID string, err error)
+}
+
+// HeaderIdentity trusts X-User-Id set by the API gateway after it authenticated the request,
+// it must be used only if the service is not reachable bypassing the gateway
+type HeaderIdentity struct{}
+
+func (HeaderIdentity) Identify(r *http.Request) (string, string, error) {
+	userID := strings.TrimSpace(r.Header.Get(HeaderUserID))
+	if userID == "" {
+		return "", "", errNoIdentity
+	}
+	token, _ := bearerToken(r)
+	return userID, token, nil
+}
+
+// BearerIdentity verifies JWT from Authorization header
+type BearerIdentity struct {
+	Verifier TokenVerifier
+}
+
+func (s BearerIdentity) Identify(r *http.Request) (string, string, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return "", "", errNoIdentity
+	}
+	userID, err := s.Verifier.VerifyAccessToken(r.Context(), token)
+	if err != nil || userID == "" {
+		return "", "", ErrUnauthorized
+	}
+	return userID, token, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	parts := strings.SplitN(r.Header.Get(HeaderAuthorization), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], Bearer) || strings.TrimSpace(parts[1]) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(parts[1]), true
+}
+
+// APIGateWayAuthMiddleware resolves the user with the first source the request has credentials for,
+// HeaderIdentity is used if no sources are given. User id is stored under ContextKeyUserID and
+// the access token under AccessTokenKey in both echo and request contexts
+func APIGateWayAuthMiddleware(next echo.HandlerFunc, sources ...IdentitySource) echo.HandlerFunc {
+	if len(sources) == 0 {
+		sources = []IdentitySource{HeaderIdentity{}}
+	}
+
+	return func(c echo.Context) error {
+		for _, source := range sources {
+			userID, token, err := source.Identify(c.Request())
+			if errors.Is(err, errNoIdentity) {
+				continue
+			}
+			if err != nil {
+				return unauthorized(c, err)
+			}
+
+			c.Set(ContextKeyUserID.String(), userID)
+			ctx := context.WithValue(c.Request().Context(), ContextKeyUserID, userID)
+			if token != "" {
+				c.Set(AccessTokenKey.String(), token)
+				ctx = context.WithValue(ctx, AccessTokenKey, token)
+			}
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+
+		return unauthorized(c, ErrUserIDIsMissing)
+	}
+}
+
+func unauthorized(c echo.Context, err error) error {
+	return JSONErr(c, err, http.StatusUnauthorized, validation.CodeError(validation.Unauthorized().Code, err))
+}
@@ -0,0 +1,99 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) VerifyAccessToken(_ context.Context, token string) (string, error) {
+	if token != "valid" {
+		return "", errors.New("invalid token")
+	}
+	return "token-user", nil
+}
+
+func TestAPIGateWayAuthMiddleware(t *testing.T) {
+	bearer := BearerIdentity{Verifier: fakeVerifier{}}
+
+	testCases := []struct {
+		name       string
+		sources    []IdentitySource
+		headers    map[string]string
+		wantStatus int
+		wantUserID string
+		wantToken  string
+	}{
+		{
+			name:       "trusted header",
+			headers:    map[string]string{HeaderUserID: "header-user"},
+			wantStatus: http.StatusOK,
+			wantUserID: "header-user",
+		},
+		{
+			name:       "missing header",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "header is not trusted in jwt mode",
+			sources:    []IdentitySource{bearer},
+			headers:    map[string]string{HeaderUserID: "header-user"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid bearer",
+			sources:    []IdentitySource{bearer},
+			headers:    map[string]string{HeaderAuthorization: "Bearer valid"},
+			wantStatus: http.StatusOK,
+			wantUserID: "token-user",
+			wantToken:  "valid",
+		},
+		{
+			name:       "invalid bearer",
+			sources:    []IdentitySource{bearer, HeaderIdentity{}},
+			headers:    map[string]string{HeaderAuthorization: "Bearer forged", HeaderUserID: "header-user"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "falls back to header",
+			sources:    []IdentitySource{bearer, HeaderIdentity{}},
+			headers:    map[string]string{HeaderUserID: "header-user"},
+			wantStatus: http.StatusOK,
+			wantUserID: "header-user",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var userID, token interface{}
+			handler := APIGateWayAuthMiddleware(func(c echo.Context) error {
+				userID = c.Get(ContextKeyUserID.String())
+				token = c.Request().Context().Value(AccessTokenKey)
+				return c.NoContent(http.StatusOK)
+			}, tc.sources...)
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantUserID != "" {
+				assert.Equal(t, tc.wantUserID, userID)
+			}
+			if tc.wantToken != "" {
+				assert.Equal(t, tc.wantToken, token)
+			}
+		})
+	}
+}
@@ -10,19 +10,26 @@ const (
 	HeaderRequestID     = "X-Request-Id"
 	krakenIPAddress     = "X-Kraken-Real-Ip"
 
+	HeaderAcceptLanguage  = "Accept-Language"
+	HeaderContentLanguage = "Content-Language"
+
 	Bearer = "Bearer"
 
-	RequestInfoKey      ContextKey = "requestInfo"
-	ContextKeyUserID    ContextKey = "userID"
-	AccessTokenKey      ContextKey = "accessToken"
-	ContextKeyLogger               = "ctxLogger"
-	ContextKeyRequestID            = "requestID"
+	RequestInfoKey   ContextKey = "requestInfo"
+	ContextKeyUserID ContextKey = "userID"
+	AccessTokenKey   ContextKey = "accessToken"
+	// ContextKeyUserLocale holds the locale chosen by the authenticated user, e.g. taken from the access token
+	ContextKeyUserLocale ContextKey = "userLocale"
+	ContextKeyLogger                = "ctxLogger"
+	ContextKeyRequestID             = "requestID"
 
 	// request id field name for logging
 	fieldNameRequestID = "request_id"
 
 	EchoContextKeyOriginalError = "echo-original-error"
 	EchoContextKeyResponseBody  = "echo-response-body"
+	// EchoContextKeyErrorReported is set by HTTPErrorHandler once the error is reported
+	EchoContextKeyErrorReported = "echo-error-reported"
 )
 
 type ContextKey string
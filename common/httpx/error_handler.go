@@ -0,0 +1,83 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+// StatusClientClosedRequest is used when the client went away before the response was ready,
+// nothing is written as nobody reads it
+const StatusClientClosedRequest = 499
+
+// NewHTTPErrorHandler renders errors returned by handlers and middlewares the same way JSONErr does.
+// It recognises *validation.Result, *echo.HTTPError, ErrHTTPResponse of outbound calls,
+// authentication errors and context cancellation, anything else is 500.
+// report is called with errors resulting in 5xx, e.g. to send them to Sentry, it can be nil
+func NewHTTPErrorHandler(report func(c echo.Context, err error)) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		status, response := errorResponse(err)
+		if status >= 500 && report != nil {
+			report(c, err)
+			c.Set(EchoContextKeyErrorReported, true)
+		}
+
+		if status == StatusClientClosedRequest || c.Request().Method == http.MethodHead {
+			c.Set(EchoContextKeyOriginalError, err)
+			err = c.NoContent(status)
+		} else {
+			err = JSONErr(c, err, status, response)
+		}
+		if err != nil {
+			c.Logger().Error(err)
+		}
+	}
+}
+
+func errorResponse(err error) (int, interface{}) {
+	var (
+		res     *validation.Result
+		httpErr *echo.HTTPError
+		respErr ErrHTTPResponse
+	)
+
+	switch {
+	case errors.As(err, &res):
+		return http.StatusBadRequest, res
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrUserIDIsMissing):
+		return http.StatusUnauthorized, validation.CodeError(validation.Unauthorized().Code, err)
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, nil
+	case errors.As(err, &httpErr):
+		return httpErr.Code, statusResult(httpErr)
+	case errors.As(err, &respErr):
+		// the called service failed, it is not the caller's fault
+		return http.StatusBadGateway, nil
+	default:
+		return http.StatusInternalServerError, nil
+	}
+}
+
+// statusResult describes echo error, e.g. unknown route or malformed body, as validation.Result
+// with the code derived from the status text: 404 is not_found
+func statusResult(httpErr *echo.HTTPError) *validation.Result {
+	out := validation.NewResult()
+	out.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(httpErr.Code)), " ", "_")
+	if msg, ok := httpErr.Message.(string); ok {
+		out.Details = msg
+	} else {
+		out.Details = http.StatusText(httpErr.Code)
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          error
+		wantStatus   int
+		wantCode     string
+		wantReported bool
+	}{
+		{
+			name:       "validation result",
+			err:        validation.NewResult().AddFieldError("email", validation.InvalidEmail()),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "wrapped validation result",
+			err:        fmt.Errorf("register: %w", validation.CodeError("user_exists", errors.New("taken"))),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "user_exists",
+		},
+		{
+			name:       "unauthorized",
+			err:        ErrUnauthorized,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   validation.Unauthorized().Code,
+		},
+		{
+			name:       "missing user id",
+			err:        ErrUserIDIsMissing,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   validation.Unauthorized().Code,
+		},
+		{
+			name:       "echo http error",
+			err:        echo.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			name:       "echo http error with custom message",
+			err:        echo.NewHTTPError(http.StatusRequestEntityTooLarge, "body is too large"),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "request_entity_too_large",
+		},
+		{
+			name:         "outbound call failed",
+			err:          NewErrHTTPResponse("http://sms", http.StatusServiceUnavailable, nil),
+			wantStatus:   http.StatusBadGateway,
+			wantReported: true,
+		},
+		{
+			name:       "client went away",
+			err:        context.Canceled,
+			wantStatus: StatusClientClosedRequest,
+		},
+		{
+			name:         "deadline exceeded",
+			err:          fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantStatus:   http.StatusGatewayTimeout,
+			wantReported: true,
+		},
+		{
+			name:         "unknown error",
+			err:          errors.New("pq: connection refused"),
+			wantStatus:   http.StatusInternalServerError,
+			wantReported: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(WithRequestID(req.Context(), "req-1"))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var reported error
+			NewHTTPErrorHandler(func(_ echo.Context, err error) { reported = err })(tc.err, c)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantReported {
+				assert.Equal(t, tc.err, reported)
+				assert.Equal(t, true, c.Get(EchoContextKeyErrorReported))
+			} else {
+				assert.Nil(t, reported)
+			}
+			assert.Equal(t, tc.err, c.Get(EchoContextKeyOriginalError))
+
+			if tc.wantStatus == StatusClientClosedRequest {
+				assert.Empty(t, rec.Body.String())
+				return
+			}
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "req-1", body["request_id"])
+			if tc.wantStatus >= 500 {
+				assert.Equal(t, map[string]interface{}{"message": internalServerError, "request_id": "req-1"}, body)
+				return
+			}
+			if tc.wantCode != "" {
+				assert.Equal(t, tc.wantCode, body["code"])
+			}
+		})
+	}
+}
+
+func TestHTTPErrorHandlerCommitted(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	require.NoError(t, c.NoContent(http.StatusAccepted))
+
+	NewHTTPErrorHandler(nil)(errors.New("late"), c)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
@@ -4,51 +4,43 @@ import (
 	"encoding/json"
 	"net/http"
 
-    "github.com/iris-contrib/schema"
-    "github.com/labstack/echo/v4"
+	"github.com/iris-contrib/schema"
+	"github.com/labstack/echo/v4"
 
-	// "github.com/levongh/profile/common/httpx/validation"
+	"github.com/levongh/profile/common/validation"
 )
 
 const (
 	internalServerError = "Internal Server Error"
 )
 
-// internalServerErrorResponse is a response to be sent to client on 5xx status
-
+// internalServerErrorResponse is a response to be sent to client on 5xx status,
+// the original error is only logged with the request id
 type internalServerErrorResponse struct {
-	Message string `json:"message, omitempty"`
+	Message   string `json:"message,omitempty"`
 	RequestID string `json:"request_id,omitempty"`
-
-	// to be removed after requiest_id is propagated to all log entries, left for convience of debugging
-	OriginalError string `json:"original_error,omitempty"`
 }
 
-func newInternalServerError(reqID string, original error) internalServerErrorResponse {
-	out := internalServerErrorResponse{
-		Message:       internalServerError,
-		RequestID:     reqID,
-	}
-
-	if original != nil {
-		out.OriginalError = original.Error()
+func newInternalServerError(reqID string) internalServerErrorResponse {
+	return internalServerErrorResponse{
+		Message:   internalServerError,
+		RequestID: reqID,
 	}
-	return out
 }
 
 // JSONErr adds original and response to echo context in order to properly log in them inside EchoLoggingMiddleware.
 // This func changes response (adds request id) in case of 5xx statuses
-// This func will add request_id if response is *validation.Result
+// This func will add request_id if response is *validation.Result and translate its messages, see Language
 func JSONErr(c echo.Context, original error, status int, response interface{}) error {
 	if original != nil {
-		c.Set(EchocontecKeyOriginalError, original)
+		c.Set(EchoContextKeyOriginalError, original)
 	}
 	if response != nil {
 		c.Set(EchoContextKeyResponseBody, response)
 	}
 
 	if status >= 500 {
-		response = newInternalServerError(GetRequestID(c.Request().Context()), original)
+		response = newInternalServerError(GetRequestID(c.Request().Context()))
 	}
 	vr, ok := response.(*validation.Result)
 	if ok {
@@ -56,6 +48,10 @@ func JSONErr(c echo.Context, original error, status int, response interface{}) e
 		if reqID != "" {
 			vr.RequestID = reqID
 		}
+
+		lang := Language(c)
+		validation.Translate(vr, lang)
+		c.Response().Header().Set(HeaderContentLanguage, lang)
 	}
 	return c.JSON(status, response)
 }
@@ -64,7 +60,7 @@ func JSONResponse(w http.ResponseWriter, response interface{}, code int) {
 	var data []byte
 	var err error
 
-	val, ok = response.([]byte)
+	val, ok := response.([]byte)
 	if ok {
 		data = val
 	} else {
@@ -86,6 +82,6 @@ func ExtractJSON(r *http.Request, to interface{}) error {
 	return json.NewDecoder(r.Body).Decode(to)
 }
 
-func ExtractQuery(r *http.Request, tp interface{}) error {
+func ExtractQuery(r *http.Request, to interface{}) error {
 	return schema.NewDecoder().Decode(to, r.URL.Query())
 }
\ No newline at end of file
@@ -0,0 +1,16 @@
+package httpx
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+// Language picks the language of error messages: the locale of the user set under ContextKeyUserLocale
+// if it is supported, otherwise the best match of Accept-Language
+func Language(c echo.Context) string {
+	if locale, _ := c.Get(ContextKeyUserLocale.String()).(string); validation.IsLanguageSupported(locale) {
+		return locale
+	}
+	return validation.MatchLanguage(c.Request().Header.Get(HeaderAcceptLanguage))
+}
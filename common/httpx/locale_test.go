@@ -0,0 +1,57 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+func TestLanguage(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		userLocale     string
+		expected       string
+	}{
+		{name: "default", expected: "en"},
+		{name: "accept language", acceptLanguage: "ru-RU,ru;q=0.9", expected: "ru"},
+		{name: "user locale", acceptLanguage: "en-US", userLocale: "ru", expected: "ru"},
+		{name: "unsupported user locale", acceptLanguage: "ru", userLocale: "de", expected: "ru"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderAcceptLanguage, tc.acceptLanguage)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			if tc.userLocale != "" {
+				c.Set(ContextKeyUserLocale.String(), tc.userLocale)
+			}
+
+			assert.Equal(t, tc.expected, Language(c))
+		})
+	}
+}
+
+func TestJSONErrTranslates(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAcceptLanguage, "ru")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	res := validation.NewResult().AddFieldError(validation.EmailField, validation.InvalidEmail())
+	require.NoError(t, JSONErr(c, nil, http.StatusBadRequest, res))
+
+	var body validation.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "email не указан или имеет неверный формат", body.Errors[0].Codes[0].Message)
+	assert.Equal(t, validation.InvalidEmail().Code, body.Errors[0].Codes[0].Code)
+	assert.Equal(t, "ru", rec.Header().Get(HeaderContentLanguage))
+}
@@ -0,0 +1,86 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+)
+
+// maxRequestIDLength limits ids accepted from callers, longer ones are replaced
+const maxRequestIDLength = 128
+
+// requestIDKey is the request context key, ContextKeyRequestID is used for echo context
+const requestIDKey ContextKey = ContextKeyRequestID
+
+// RequestIDMiddleware takes X-Request-Id of the caller or generates a new one. The id is stored
+// in both echo and request contexts, returned in the response header and tagged on the current span,
+// so tracing middleware has to be registered before this one
+func RequestIDMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(HeaderRequestID)
+		if !isRequestIDValid(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(ContextKeyRequestID, id)
+		c.SetRequest(c.Request().WithContext(WithRequestID(c.Request().Context(), id)))
+		c.Response().Header().Set(HeaderRequestID, id)
+
+		if span := opentracing.SpanFromContext(c.Request().Context()); span != nil {
+			span.SetTag(fieldNameRequestID, id)
+		}
+
+		return next(c)
+	}
+}
+
+// isRequestIDValid accepts only printable ASCII so ids are safe to log and pass on
+func isRequestIDValid(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a copy of ctx carrying the request id,
+// it is used to keep the id in background work started by a request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// GetRequestID returns id set by RequestIDMiddleware, empty if there is none
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// RequestIDTransport passes request id of the outgoing request context to the called service
+type RequestIDTransport struct {
+	// Base is used to make requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+func (t RequestIDTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := GetRequestID(r.Context())
+	if id == "" || r.Header.Get(HeaderRequestID) != "" {
+		return base.RoundTrip(r)
+	}
+
+	// RoundTripper must not modify the request
+	r = r.Clone(r.Context())
+	r.Header.Set(HeaderRequestID, id)
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,77 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "accepted", incoming: "abc-123", keep: true},
+		{name: "generated if missing", incoming: ""},
+		{name: "replaced if too long", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "replaced if not printable", incoming: "abc\x01"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderRequestID, tc.incoming)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var fromCtx, fromEcho interface{}
+			handler := RequestIDMiddleware(func(c echo.Context) error {
+				fromCtx = GetRequestID(c.Request().Context())
+				fromEcho = c.Get(ContextKeyRequestID)
+				return c.NoContent(http.StatusOK)
+			})
+			assert.NoError(t, handler(c))
+
+			id := rec.Header().Get(HeaderRequestID)
+			assert.NotEmpty(t, id)
+			if tc.keep {
+				assert.Equal(t, tc.incoming, id)
+			} else {
+				assert.NotEqual(t, tc.incoming, id)
+			}
+			assert.Equal(t, id, fromCtx)
+			assert.Equal(t, id, fromEcho)
+		})
+	}
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(HeaderRequestID)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: RequestIDTransport{}}
+
+	req, err := http.NewRequestWithContext(WithRequestID(context.Background(), "abc-123"), http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close() // nolint:errcheck
+	assert.Equal(t, "abc-123", got)
+	assert.Empty(t, req.Header.Get(HeaderRequestID), "original request must not be modified")
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close() // nolint:errcheck
+	assert.Empty(t, got)
+}
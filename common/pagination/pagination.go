@@ -0,0 +1,239 @@
+// Package pagination parses list parameters (limit, cursor or offset, order_by)
+// against an allow-list of columns and turns them into keyset SQL fragments.
+//
+// Usage:
+//
+//	page, res := pagination.Parse(c.QueryParams(), storage.SessionsPage)
+//	if !res.IsValid() {
+//	    return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+//	}
+//	where, args := page.Where(2) // $1 is already used by the query
+//	query := "SELECT ... WHERE profile_id = $1 AND " + where + " " + page.OrderBy() + " " + page.Limit()
+//
+// Limit fetches one extra row, Next trims it and returns the cursor of the next page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/levongh/profile/common/validation"
+)
+
+const (
+	LimitParam   = "limit"
+	OffsetParam  = "offset"
+	CursorParam  = "cursor"
+	OrderByParam = "order_by"
+
+	// descPrefix in order_by sorts in descending order, e.g. order_by=-created_at
+	descPrefix = "-"
+
+	// timeLayout has fixed width, so formatted values sort the same as times
+	timeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// Spec describes how a resource can be listed
+type Spec struct {
+	// Columns maps order_by values to SQL columns, only these can be used for ordering
+	Columns map[string]string
+	// DefaultOrder is used when order_by is not provided, it may have descPrefix
+	DefaultOrder string
+	// TieBreaker is unique SQL column making the order stable, e.g. id
+	TieBreaker   string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Page is a validated request of one page
+type Page struct {
+	column string // order_by value without prefix
+	sql    string
+	tie    string
+	desc   bool
+	limit  int
+	offset int
+	cursor *cursor
+}
+
+// Links are returned by list endpoints, Next is empty on the last page
+type Links struct {
+	Next string `json:"next,omitempty"`
+}
+
+type cursor struct {
+	Column string `json:"c"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v"`
+	ID     string `json:"id"`
+}
+
+// Parse validates list parameters, the result lists all bad parameters at once
+func Parse(values url.Values, spec Spec) (*Page, *validation.Result) {
+	out := validation.NewResult()
+	p := &Page{tie: spec.TieBreaker, limit: spec.DefaultLimit}
+
+	order := values.Get(OrderByParam)
+	if order == "" {
+		order = spec.DefaultOrder
+	}
+	p.desc = strings.HasPrefix(order, descPrefix)
+	p.column = strings.TrimPrefix(order, descPrefix)
+	col, ok := spec.Columns[p.column]
+	if !ok {
+		out.AddFieldError(OrderByParam, validation.InvalidOrderColumn())
+	}
+	p.sql = col
+
+	if v := values.Get(LimitParam); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > spec.MaxLimit {
+			out.AddFieldError(LimitParam, validation.InvalidPageLimit())
+		}
+		p.limit = limit
+	}
+
+	if v := values.Get(OffsetParam); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			out.AddFieldError(OffsetParam, validation.InvalidPageOffset())
+		}
+		p.offset = offset
+	}
+
+	if v := values.Get(CursorParam); v != "" {
+		c, err := decodeCursor(v)
+		// cursor is only valid for the order it was issued for
+		if err != nil || c.Column != p.column || c.Desc != p.desc || p.offset != 0 {
+			out.AddFieldError(CursorParam, validation.InvalidCursor())
+		}
+		p.cursor = c
+	}
+
+	return p, out
+}
+
+// Where returns condition selecting rows after the cursor, placeholders start
+// from $firstArg. It is TRUE if there is no cursor
+func (p *Page) Where(firstArg int) (string, []interface{}) {
+	if p.cursor == nil {
+		return "TRUE", nil
+	}
+	op := ">"
+	if p.desc {
+		op = "<"
+	}
+	cond := fmt.Sprintf("(%s, %s) %s ($%d, $%d)", p.sql, p.tie, op, firstArg, firstArg+1)
+	return cond, []interface{}{p.cursor.Value, p.cursor.ID}
+}
+
+func (p *Page) OrderBy() string {
+	dir := "ASC"
+	if p.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s, %s %s", p.sql, dir, p.tie, dir)
+}
+
+// Limit fetches one row more than requested, so Next can tell if there is a next page
+func (p *Page) Limit() string {
+	return fmt.Sprintf("LIMIT %d OFFSET %d", p.limit+1, p.offset)
+}
+
+// Column returns order_by value without direction, it selects the key of the items
+func (p *Page) Column() string {
+	return p.column
+}
+
+// Next trims the extra row fetched by Limit and returns the number of items
+// to keep and the cursor of the next page, which is empty on the last page.
+// key returns the value of the order column and the tie-breaker of i-th item
+func (p *Page) Next(n int, key func(i int) (value, id string)) (int, string) {
+	if n <= p.limit {
+		return n, ""
+	}
+
+	value, id := key(p.limit - 1)
+	c := cursor{Column: p.column, Desc: p.desc, Value: value, ID: id}
+	b, _ := json.Marshal(c) // nolint:errcheck // can't fail for strings
+	return p.limit, base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Slice applies the page to n items kept in memory, it returns indexes of the
+// items in page order including the extra one. It mirrors Where, OrderBy and Limit
+// for in-memory storage
+func (p *Page) Slice(n int, key func(i int) (value, id string)) []int {
+	less := func(a, b [2]string) bool {
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return a[1] < b[1]
+	}
+
+	keys := make([][2]string, n)
+	idx := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		value, id := key(i)
+		keys[i] = [2]string{value, id}
+
+		if p.cursor != nil {
+			after := [2]string{p.cursor.Value, p.cursor.ID}
+			if (!p.desc && !less(after, keys[i])) || (p.desc && !less(keys[i], after)) {
+				continue
+			}
+		}
+		idx = append(idx, i)
+	}
+
+	sort.Slice(idx, func(a, b int) bool {
+		if p.desc {
+			return less(keys[idx[b]], keys[idx[a]])
+		}
+		return less(keys[idx[a]], keys[idx[b]])
+	})
+
+	if p.offset >= len(idx) {
+		return nil
+	}
+	idx = idx[p.offset:]
+	if len(idx) > p.limit+1 {
+		idx = idx[:p.limit+1]
+	}
+	return idx
+}
+
+// NextLink returns the request URL pointing to the next page, offset is replaced by the cursor
+func NextLink(u *url.URL, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	next := *u
+	q := next.Query()
+	q.Del(OffsetParam)
+	q.Set(CursorParam, cursor)
+	next.RawQuery = q.Encode()
+	return next.RequestURI()
+}
+
+// TimeValue formats time as cursor value, it sorts as text the same way as time
+func TimeValue(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,111 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSpec = Spec{
+	Columns: map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	DefaultOrder: "-created_at",
+	TieBreaker:   "id",
+	DefaultLimit: 2,
+	MaxLimit:     10,
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		invalid []string
+		orderBy string
+	}{
+		{name: "defaults", query: "", orderBy: "ORDER BY created_at DESC, id DESC"},
+		{name: "ascending", query: "order_by=name&limit=10", orderBy: "ORDER BY name ASC, id ASC"},
+		{name: "unknown column", query: "order_by=password", invalid: []string{OrderByParam}},
+		{name: "injection", query: "order_by=name%3BDROP%20TABLE%20profiles", invalid: []string{OrderByParam}},
+		{name: "limit too big", query: "limit=11", invalid: []string{LimitParam}},
+		{name: "limit not a number", query: "limit=ten&offset=-1", invalid: []string{LimitParam, OffsetParam}},
+		{name: "malformed cursor", query: "cursor=%21%21", invalid: []string{CursorParam}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			p, res := Parse(values, testSpec)
+
+			var invalid []string
+			for _, e := range res.Errors {
+				invalid = append(invalid, e.Name)
+			}
+			assert.Equal(t, tc.invalid, invalid)
+			if tc.orderBy != "" {
+				assert.Equal(t, tc.orderBy, p.OrderBy())
+			}
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	names := []string{"d", "a", "c", "e", "b"}
+	key := func(i int) (string, string) {
+		return names[i], fmt.Sprint(i)
+	}
+
+	values := url.Values{OrderByParam: {"name"}}
+	var got []string
+	for pages := 0; pages < 5; pages++ {
+		p, res := Parse(values, testSpec)
+		require.True(t, res.IsValid())
+
+		idx := p.Slice(len(names), key)
+		n, cursor := p.Next(len(idx), func(i int) (string, string) {
+			return key(idx[i])
+		})
+		for _, i := range idx[:n] {
+			got = append(got, names[i])
+		}
+
+		if cursor == "" {
+			break
+		}
+		values.Set(CursorParam, cursor)
+
+		where, args := p.Where(2)
+		if pages > 0 {
+			assert.Equal(t, "(name, id) > ($2, $3)", where)
+			assert.Len(t, args, 2)
+		}
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, got)
+}
+
+func TestCursorMustMatchOrder(t *testing.T) {
+	p, _ := Parse(url.Values{LimitParam: {"1"}}, testSpec)
+	_, cursor := p.Next(2, func(int) (string, string) { return "2026-10-17T00:00:00.000000000Z", "1" })
+	require.NotEmpty(t, cursor)
+
+	_, res := Parse(url.Values{CursorParam: {cursor}, OrderByParam: {"name"}}, testSpec)
+	assert.False(t, res.IsValid())
+
+	_, res = Parse(url.Values{CursorParam: {cursor}}, testSpec)
+	assert.True(t, res.IsValid())
+}
+
+func TestNextLink(t *testing.T) {
+	u, err := url.Parse("/api/v1/profile/sessions?limit=2&offset=4")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v1/profile/sessions?cursor=abc&limit=2", NextLink(u, "abc"))
+	assert.Empty(t, NextLink(u, ""))
+}
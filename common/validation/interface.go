@@ -1,46 +1,39 @@
 package validation
 
-import (
-    "reflect"
-    "strings"
-)
-
 type Validatable interface {
     Validate() *Result // Validate must ALWAYS be declared on a pointer to a struct
 }
 
-// Validate clean data (strips spaces from strings) and validates
+// Validate cleans data according to `normalize` tags (strings are trimmed by default), checks `validate` tags
+// and calls Validate of the struct, a wrong `normalize` tag is reported in details without other checks
 // Make sure to provide a pointer to the struct or else it will fail
 func Validate(v Validatable) *Result {
-    trimSpaces(v)
-    return v.Validate()
-}
-
-// will trim spaces from all string and *string fields of a struct
-func trimSpaces(i interface{}) {
-    v := reflect.ValueOf(i)
-
-    // we need only pointers to make sure we changes will be seen outside
-    if v.Kind() != reflect.Ptr {
-        return // nothing will happen
+    if err := Normalize(v); err != nil {
+        return NewResult().AddDetails(err.Error())
     }
+    out := defaultStructValidator.Struct(v)
+    if res := v.Validate(); res != nil {
+        out.merge(res)
+    }
+    return out
+}
 
-    // need to dereference pointer to access fields
-    v = v.Elem()
-
-    for i := 0; i < v.NumField(); i++ {
-        str, ok := v.Field(i).Interface().(string)
-        if ok {
-            // we can set if it is a simple string
-            str = strings.TrimSpace(str)
-            v.Field(i).SetString(str)
-        }
-
-        ptr, ok := v.Field(i).Interface().(*string)
-        if ok && ptr != nil {
-            // if this is a pointer we set it like this
-            *ptr = strings.TrimSpace(*ptr)
-            v.Field(i).Set(reflect.ValueOf(ptr))
+// merge adds errors of other result keeping one entry per field, details and code are taken
+// from other if it has them
+func (r *Result) merge(other *Result) {
+    for _, e := range other.Errors {
+        for _, ed := range e.Codes {
+            r.AddFieldErrorWithData(e.Name, ed, e.Data, e.Index)
         }
     }
-}
\ No newline at end of file
+    if other.Details != "" {
+        r.Details = other.Details
+    }
+    if other.Code != "" {
+        r.Code = other.Code
+    }
+    for k, v := range other.Meta {
+        r.AddMetaInfo(k, v)
+    }
+    r.Events = other.Events
+}
@@ -0,0 +1,175 @@
+package validation
+
+import (
+    "embed"
+    "encoding/json"
+    "fmt"
+    "io/fs"
+    "path"
+    "regexp"
+    "sort"
+    "strings"
+
+    "golang.org/x/text/language"
+)
+
+// DefaultLanguage is used when none of the preferred languages is supported,
+// its messages are also used for codes missing in other translations
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// rxPlaceholder matches placeholders of messages like {max}, they are replaced with values of Error.Data
+var rxPlaceholder = regexp.MustCompile(`{([a-z_]+)}`)
+
+// Catalog holds messages of error codes for every supported language
+type Catalog struct {
+    messages  map[string]map[string]string
+    languages []string
+    matcher   language.Matcher
+}
+
+var defaultCatalog = mustCatalog(NewCatalog(localeFiles))
+
+// NewCatalog reads translations from json files named after the language, e.g. locales/ru.json,
+// each file maps error codes to messages. Translation for DefaultLanguage is required
+func NewCatalog(fsys fs.FS) (*Catalog, error) {
+    files, err := fs.Glob(fsys, "locales/*.json")
+    if err != nil {
+        return nil, err
+    }
+
+    c := &Catalog{messages: make(map[string]map[string]string, len(files))}
+    for _, file := range files {
+        lang := strings.TrimSuffix(path.Base(file), ".json")
+        if _, err := language.Parse(lang); err != nil {
+            return nil, fmt.Errorf("translation %s: %w", file, err)
+        }
+
+        data, err := fs.ReadFile(fsys, file)
+        if err != nil {
+            return nil, err
+        }
+        messages := make(map[string]string)
+        if err := json.Unmarshal(data, &messages); err != nil {
+            return nil, fmt.Errorf("translation %s: %w", file, err)
+        }
+        c.messages[lang] = messages
+    }
+    if _, ok := c.messages[DefaultLanguage]; !ok {
+        return nil, fmt.Errorf("translation for %s is missing", DefaultLanguage)
+    }
+
+    // the default language goes first, so it is matched when nothing else is
+    c.languages = append(c.languages, DefaultLanguage)
+    for lang := range c.messages {
+        if lang != DefaultLanguage {
+            c.languages = append(c.languages, lang)
+        }
+    }
+    sort.Strings(c.languages[1:])
+
+    tags := make([]language.Tag, 0, len(c.languages))
+    for _, lang := range c.languages {
+        tags = append(tags, language.Make(lang))
+    }
+    c.matcher = language.NewMatcher(tags)
+
+    return c, nil
+}
+
+func mustCatalog(c *Catalog, err error) *Catalog {
+    if err != nil {
+        panic(err)
+    }
+    return c
+}
+
+// Languages returns supported languages, the default one goes first
+func (c *Catalog) Languages() []string {
+    return append([]string(nil), c.languages...)
+}
+
+// IsSupported reports whether there is a translation for the language
+func (c *Catalog) IsSupported(lang string) bool {
+    _, ok := c.messages[lang]
+    return ok
+}
+
+// Match picks the best supported language for preferences given either as language tags
+// or as Accept-Language header values, DefaultLanguage is returned if nothing matches
+func (c *Catalog) Match(preferences ...string) string {
+    // the matcher falls back to the first language, which is the default one
+    _, index := language.MatchStrings(c.matcher, preferences...)
+    return c.languages[index]
+}
+
+// Message returns message of the code in the language with placeholders replaced by data,
+// false is returned if the code is unknown or data lacks some of the placeholders
+func (c *Catalog) Message(lang, code string, data map[string]interface{}) (string, bool) {
+    msg, ok := c.messages[lang][code]
+    if !ok {
+        msg, ok = c.messages[DefaultLanguage][code]
+    }
+    if !ok {
+        return "", false
+    }
+
+    complete := true
+    msg = rxPlaceholder.ReplaceAllStringFunc(msg, func(placeholder string) string {
+        value, ok := data[placeholder[1:len(placeholder)-1]]
+        if !ok {
+            complete = false
+            return placeholder
+        }
+        if values, ok := value.([]string); ok {
+            return strings.Join(values, ", ")
+        }
+        return fmt.Sprint(value)
+    })
+    return msg, complete
+}
+
+// Translate replaces messages of the result with ones in the language.
+// Details are replaced with the message of the code even if they hold the text of the original error,
+// e.g. set by CodeError, as it isn't translated. Messages without translation are not changed
+func (c *Catalog) Translate(r *Result, lang string) {
+    if r == nil {
+        return
+    }
+
+    if r.Code != "" {
+        if msg, ok := c.Message(lang, r.Code, r.Meta); ok {
+            r.Details = msg
+        }
+    }
+
+    for _, e := range r.Errors {
+        for i := range e.Codes {
+            if msg, ok := c.Message(lang, e.Codes[i].Code, e.Data); ok {
+                e.Codes[i].Message = msg
+            }
+        }
+    }
+}
+
+// MatchLanguage picks the best supported language, see Catalog.Match
+func MatchLanguage(preferences ...string) string {
+    return defaultCatalog.Match(preferences...)
+}
+
+// IsLanguageSupported reports whether error messages are translated to the language
+func IsLanguageSupported(lang string) bool {
+    return defaultCatalog.IsSupported(lang)
+}
+
+// Languages returns languages error messages are translated to
+func Languages() []string {
+    return defaultCatalog.Languages()
+}
+
+// Translate replaces messages of the result with ones in the language, see Catalog.Translate
+func Translate(r *Result, lang string) {
+    defaultCatalog.Translate(r, lang)
+}
@@ -0,0 +1,159 @@
+package validation
+
+import (
+    "errors"
+    "testing"
+    "testing/fstest"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestCatalogTranslationsComplete(t *testing.T) {
+    langs := Languages()
+    require.Equal(t, DefaultLanguage, langs[0])
+    require.Contains(t, langs, "ru")
+
+    for _, lang := range langs[1:] {
+        for code := range defaultCatalog.messages[DefaultLanguage] {
+            assert.Contains(t, defaultCatalog.messages[lang], code, "%s translation", lang)
+        }
+        for code := range defaultCatalog.messages[lang] {
+            assert.Contains(t, defaultCatalog.messages[DefaultLanguage], code, "%s translation", lang)
+        }
+    }
+}
+
+func TestCatalogDefaultMessages(t *testing.T) {
+    details := []ErrorDetails{
+        EitherPhoneOrEmail(), EmptyBirthDate(), InvalidBirthDate(), NotOnlyLetters(), UserAlreadyExists(),
+        Unauthorized(), EmptyPassword(), InvalidPassword(), WrongPassword(), RulesNotAccepted(),
+        NameIsTooShort(), TooYoungAge(), WrongPhoneFormat(), InvalidEmail(), UnknownCountry(),
+        InvalidPhone(), EmptyDevice(), EmptyRefreshToken(), InvalidAntiPhishingCode(),
+        InvalidCountryCallingCodeFormat(), WrongCountryCallingCode(), InvalidPageLimit(), InvalidPageOffset(),
+        InvalidCursor(), InvalidOrderColumn(), InvalidOtpCode(), OtpSentRecently(), InvalidGrantType(),
+        InvalidAction(), Invalid2FAMethod(), InvalidCode(), InvalidKey(), InvalidResetToken(),
+        InvalidConfirmPassword(), InvalidProfileID(), InvalidKeys(), FieldRequired(), InvalidUUID(),
+        InvalidURL(), InvalidValue(), VerificationRequired(),
+    }
+    for _, ed := range details {
+        msg, ok := defaultCatalog.Message(DefaultLanguage, ed.Code, map[string]interface{}{"rule": "x"})
+        require.True(t, ok, ed.Code)
+        if ed.Code != InvalidValue().Code {
+            assert.Equal(t, ed.Message, msg, ed.Code)
+        }
+    }
+
+    results := []*Result{
+        BothEmailAndPhoneProvided(), ProfileNotFound(), EmailAlreadyVerified(), TwoFactorAlreadyEnabled(),
+        TwoFactorNotEnabled(), SessionNotFound(), InvalidCredentials(), TwoFactorRequired(), InvalidRefreshToken(),
+        TooManyAttempts(),
+    }
+    for _, r := range results {
+        msg, ok := defaultCatalog.Message(DefaultLanguage, r.Code, nil)
+        require.True(t, ok, r.Code)
+        assert.Equal(t, r.Details, msg, r.Code)
+    }
+
+    // details of these results are the text of the original error, so only the code is known
+    for _, r := range []*Result{CaptchaError(errors.New("timeout")), CodeError(OtpSentRecently().Code, errors.New("wait"))} {
+        _, ok := defaultCatalog.Message(DefaultLanguage, r.Code, nil)
+        assert.True(t, ok, r.Code)
+    }
+
+    // dynamic messages are rendered from data the same way
+    msg, _ := defaultCatalog.Message(DefaultLanguage, InvalidProfileIDs(50).Code, map[string]interface{}{"max": 50})
+    assert.Equal(t, InvalidProfileIDs(50).Message, msg)
+    msg, _ = defaultCatalog.Message(DefaultLanguage, InvalidIPAddress("x").Code, map[string]interface{}{"ip_address": "x"})
+    assert.Equal(t, InvalidIPAddress("x").Message, msg)
+}
+
+func TestMatchLanguage(t *testing.T) {
+    testCases := []struct {
+        name        string
+        preferences []string
+        expected    string
+    }{
+        {name: "empty", preferences: []string{""}, expected: "en"},
+        {name: "nothing", expected: "en"},
+        {name: "unsupported", preferences: []string{"de-DE,de;q=0.9"}, expected: "en"},
+        {name: "region", preferences: []string{"ru-RU"}, expected: "ru"},
+        {name: "weights", preferences: []string{"en;q=0.5, ru;q=0.8"}, expected: "ru"},
+        {name: "first supported", preferences: []string{"de, ru;q=0.9, en;q=0.8"}, expected: "ru"},
+        {name: "english", preferences: []string{"en-GB,en;q=0.9,ru;q=0.8"}, expected: "en"},
+        {name: "malformed", preferences: []string{"@@@"}, expected: "en"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            assert.Equal(t, tc.expected, MatchLanguage(tc.preferences...))
+        })
+    }
+}
+
+func TestTranslate(t *testing.T) {
+    res := NewResult()
+    res.AddFieldErrorWithData("password", TooShort(), map[string]interface{}{"min": "8"}, 0)
+    res.AddFieldErrorWithData("status", NotAllowedValue(), map[string]interface{}{"allowed": []string{"a", "b"}}, 0)
+    res.AddFieldError("email", InvalidEmail())
+    res.AddFieldError("name", TooLong())
+    res.AddFieldError("custom", ErrorDetails{Code: "custom_code", Message: "custom message"})
+
+    Translate(res, "ru")
+
+    assert.Equal(t, "значение слишком короткое, минимум 8", res.Errors[0].Codes[0].Message)
+    assert.Equal(t, "значение должно быть одним из: a, b", res.Errors[1].Codes[0].Message)
+    assert.Equal(t, "email не указан или имеет неверный формат", res.Errors[2].Codes[0].Message)
+    // data for the placeholder is missing
+    assert.Equal(t, TooLong().Message, res.Errors[3].Codes[0].Message)
+    assert.Equal(t, "custom message", res.Errors[4].Codes[0].Message)
+    assert.Equal(t, "custom_code", res.Errors[4].Codes[0].Code)
+
+    // english messages are rendered with data too
+    res = NewResult().AddFieldErrorWithData("password", TooShort(), map[string]interface{}{"min": 8}, 0)
+    Translate(res, "en")
+    assert.Equal(t, "value is too short, minimum is 8", res.Errors[0].Codes[0].Message)
+}
+
+func TestTranslateDetails(t *testing.T) {
+    res := ProfileNotFound()
+    Translate(res, "ru")
+    assert.Equal(t, "профиль не найден", res.Details)
+    assert.Equal(t, "profile_not_found", res.Code)
+
+    // details of the original error are replaced with the message of the code
+    res = CodeError(Unauthorized().Code, errors.New("token is expired"))
+    Translate(res, "ru")
+    assert.Equal(t, "требуется авторизация", res.Details)
+
+    res = CaptchaError(errors.New("timeout-or-duplicate"))
+    Translate(res, "ru")
+    assert.Equal(t, "проверка капчи не пройдена", res.Details)
+
+    // codes without message keep details
+
+    res = DBOperationError(errors.New("connection refused"))
+    Translate(res, "ru")
+    assert.Equal(t, "connection refused", res.Details)
+
+    Translate(nil, "ru")
+}
+
+func TestNewCatalogErrors(t *testing.T) {
+    _, err := NewCatalog(fstest.MapFS{"locales/ru.json": {Data: []byte(`{"a": "б"}`)}})
+    assert.Error(t, err, "default language is required")
+
+    _, err = NewCatalog(fstest.MapFS{"locales/en.json": {Data: []byte(`{"a": `)}})
+    assert.Error(t, err, "malformed json")
+
+    c, err := NewCatalog(fstest.MapFS{
+        "locales/en.json": {Data: []byte(`{"a": "a {n}"}`)},
+        "locales/hy.json": {Data: []byte(`{}`)},
+    })
+    require.NoError(t, err)
+    assert.Equal(t, []string{"en", "hy"}, c.Languages())
+    // missing translation falls back to the default language
+    msg, ok := c.Message("hy", "a", map[string]interface{}{"n": 1})
+    assert.True(t, ok)
+    assert.Equal(t, "a 1", msg)
+}
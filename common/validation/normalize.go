@@ -0,0 +1,225 @@
+package validation
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "sync"
+
+    "golang.org/x/text/unicode/norm"
+)
+
+// NormalizeTag lists normalizations applied to the string field in order, e.g. `normalize:"trim,lower"`.
+// Fields without the tag are trimmed, `normalize:"-"` leaves the field and everything inside it as is
+const NormalizeTag = "normalize"
+
+// options of NormalizeTag
+const (
+    NormalizeTrim           = "trim"
+    NormalizeLower          = "lower"
+    NormalizeCollapseSpaces = "collapse_spaces"
+    NormalizeNFC            = "nfc"
+)
+
+type normalizer func(string) string
+
+var (
+    normalizers = map[string]normalizer{
+        NormalizeTrim:           strings.TrimSpace,
+        NormalizeLower:          strings.ToLower,
+        NormalizeCollapseSpaces: func(s string) string { return strings.Join(strings.Fields(s), " ") },
+        NormalizeNFC:            norm.NFC.String,
+    }
+
+    defaultNormalizers = []normalizer{strings.TrimSpace}
+)
+
+// fieldNormalizer is parsed NormalizeTag of the struct field
+type fieldNormalizer struct {
+    index int
+    ops   []normalizer
+}
+
+// structNormalizers caches parsed tags, reflect.Type of the struct -> []fieldNormalizer
+var structNormalizers sync.Map
+
+// visitKey identifies pointer, map or slice already walked, type is a part of it as a struct
+// and its first field share the address
+type visitKey struct {
+    ptr uintptr
+    typ reflect.Type
+}
+
+// Normalize cleans string fields of the struct according to their NormalizeTag, nested structs,
+// slices, maps and pointers are walked too, each of them once. i must be a pointer, otherwise nothing
+// is changed. An error is returned if the struct has a tag with unknown option
+func Normalize(i interface{}) error {
+    v := reflect.ValueOf(i)
+    if v.Kind() != reflect.Ptr || v.IsNil() {
+        return nil
+    }
+    return normalizeValue(v, defaultNormalizers, make(map[visitKey]bool))
+}
+
+// normalizeValue applies ops to strings of v, struct fields use their own tags instead
+func normalizeValue(v reflect.Value, ops []normalizer, visited map[visitKey]bool) error {
+    switch v.Kind() {
+    case reflect.String:
+        if v.CanSet() {
+            v.SetString(applyNormalizers(v.String(), ops))
+        }
+    case reflect.Ptr:
+        if v.IsNil() || visit(v, visited) {
+            return nil
+        }
+        return normalizeValue(v.Elem(), ops, visited)
+    case reflect.Interface:
+        // value inside of interface can't be changed in place, so a normalized copy is set back
+        if v.IsNil() || !v.CanSet() {
+            return nil
+        }
+        elem := reflect.New(v.Elem().Type()).Elem()
+        elem.Set(v.Elem())
+        if err := normalizeValue(elem, ops, visited); err != nil {
+            return err
+        }
+        v.Set(elem)
+    case reflect.Struct:
+        fields, err := typeNormalizers(v.Type())
+        if err != nil {
+            return err
+        }
+        for _, f := range fields {
+            if err := normalizeValue(v.Field(f.index), f.ops, visited); err != nil {
+                return err
+            }
+        }
+    case reflect.Slice:
+        if v.IsNil() || visit(v, visited) {
+            return nil
+        }
+        return normalizeElems(v, ops, visited)
+    case reflect.Array:
+        return normalizeElems(v, ops, visited)
+    case reflect.Map:
+        if v.IsNil() || visit(v, visited) {
+            return nil
+        }
+        iter := v.MapRange()
+        for iter.Next() {
+            // map values aren't addressable, so they are normalized as copies
+            elem := reflect.New(iter.Value().Type()).Elem()
+            elem.Set(iter.Value())
+            if err := normalizeValue(elem, ops, visited); err != nil {
+                return err
+            }
+            v.SetMapIndex(iter.Key(), elem)
+        }
+    }
+    return nil
+}
+
+func normalizeElems(v reflect.Value, ops []normalizer, visited map[visitKey]bool) error {
+    for i := 0; i < v.Len(); i++ {
+        if err := normalizeValue(v.Index(i), ops, visited); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// visit marks pointer, map or slice as walked and reports whether it was walked before,
+// so self-referencing values don't loop forever
+func visit(v reflect.Value, visited map[visitKey]bool) bool {
+    key := visitKey{ptr: v.Pointer(), typ: v.Type()}
+    if visited[key] {
+        return true
+    }
+    visited[key] = true
+    return false
+}
+
+// typeNormalizers returns normalizers of exported fields of the struct type to walk. Tags of the
+// struct types nested in it are checked the first time too, so a wrong tag is reported even if
+// the field is empty in the request
+func typeNormalizers(t reflect.Type) ([]fieldNormalizer, error) {
+    if cached, ok := structNormalizers.Load(t); ok {
+        return cached.([]fieldNormalizer), nil
+    }
+    if err := parseNormalizeTags(t, make(map[reflect.Type]bool)); err != nil {
+        return nil, err
+    }
+    cached, _ := structNormalizers.Load(t)
+    return cached.([]fieldNormalizer), nil
+}
+
+// parseNormalizeTags parses tags of the struct types found in t and caches the valid ones
+func parseNormalizeTags(t reflect.Type, seen map[reflect.Type]bool) error {
+    switch t.Kind() {
+    case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+        return parseNormalizeTags(t.Elem(), seen)
+    case reflect.Struct:
+    default:
+        return nil
+    }
+    if seen[t] {
+        return nil
+    }
+    seen[t] = true
+    if _, ok := structNormalizers.Load(t); ok {
+        return nil
+    }
+
+    fields := make([]fieldNormalizer, 0, t.NumField())
+    for i := 0; i < t.NumField(); i++ {
+        field := t.Field(i)
+        if field.PkgPath != "" {
+            continue // unexported
+        }
+        ops, ok, err := fieldNormalizers(t, field)
+        if err != nil {
+            return err
+        }
+        if !ok {
+            continue
+        }
+        if err := parseNormalizeTags(field.Type, seen); err != nil {
+            return err
+        }
+        fields = append(fields, fieldNormalizer{index: i, ops: ops})
+    }
+    structNormalizers.Store(t, fields)
+    return nil
+}
+
+// fieldNormalizers parses NormalizeTag of the field, false is returned for fields to skip
+func fieldNormalizers(t reflect.Type, field reflect.StructField) ([]normalizer, bool, error) {
+    tag, ok := field.Tag.Lookup(NormalizeTag)
+    if !ok {
+        return defaultNormalizers, true, nil
+    }
+    if tag == "-" {
+        return nil, false, nil
+    }
+    if tag == "" {
+        return nil, true, nil
+    }
+
+    names := strings.Split(tag, ",")
+    ops := make([]normalizer, 0, len(names))
+    for _, name := range names {
+        fn, ok := normalizers[strings.TrimSpace(name)]
+        if !ok {
+            return nil, false, fmt.Errorf("validation: unknown %s option %q of field %s.%s", NormalizeTag, name, t, field.Name)
+        }
+        ops = append(ops, fn)
+    }
+    return ops, true, nil
+}
+
+func applyNormalizers(s string, ops []normalizer) string {
+    for _, op := range ops {
+        s = op(s)
+    }
+    return s
+}
@@ -0,0 +1,123 @@
+package validation
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+type normalizeAddress struct {
+    City  string  `json:"city" normalize:"collapse_spaces"`
+    Notes *string `json:"notes"`
+}
+
+type normalizeContact struct {
+    Email string `json:"email" normalize:"trim,lower"`
+}
+
+type normalizeRequest struct {
+    Name        string                      `json:"name" normalize:"collapse_spaces,nfc"`
+    Nickname    *string                     `json:"nickname"`
+    Password    string                      `json:"password" normalize:"-"`
+    Raw         string                      `json:"raw" normalize:""`
+    Address     normalizeAddress            `json:"address"`
+    Billing     *normalizeAddress           `json:"billing"`
+    Contacts    []normalizeContact          `json:"contacts"`
+    Tags        []string                    `json:"tags" normalize:"trim,lower"`
+    Labels      map[string]string           `json:"labels"`
+    ByKind      map[string]normalizeContact `json:"by_kind"`
+    Extra       map[string]interface{}      `json:"extra"`
+    Skipped     normalizeContact            `json:"skipped" normalize:"-"`
+    unexported  string
+}
+
+func strPtr(s string) *string {
+    return &s
+}
+
+func TestNormalize(t *testing.T) {
+    req := normalizeRequest{
+        Name:     "  Amélie   De  Paris ",
+        Nickname: strPtr(" ami "),
+        Password: " secret ",
+        Raw:      " raw ",
+        Address:  normalizeAddress{City: " New   York ", Notes: strPtr(" ring twice ")},
+        Billing:  &normalizeAddress{City: " Yerevan "},
+        Contacts: []normalizeContact{{Email: " Anna@Example.COM "}},
+        Tags:     []string{" VIP ", "New"},
+        Labels:   map[string]string{"source": " web "},
+        ByKind:   map[string]normalizeContact{"work": {Email: " Work@Example.com"}},
+        Extra:    map[string]interface{}{"comment": " hi ", "count": 1},
+        Skipped:  normalizeContact{Email: " Keep@Me "},
+    }
+    req.unexported = " keep "
+
+    require.NoError(t, Normalize(&req))
+
+    assert.Equal(t, "Amélie De Paris", req.Name)
+    assert.Equal(t, "ami", *req.Nickname)
+    assert.Equal(t, " secret ", req.Password)
+    assert.Equal(t, " raw ", req.Raw)
+    assert.Equal(t, "New York", req.Address.City)
+    assert.Equal(t, "ring twice", *req.Address.Notes)
+    assert.Equal(t, "Yerevan", req.Billing.City)
+    assert.Equal(t, "anna@example.com", req.Contacts[0].Email)
+    assert.Equal(t, []string{"vip", "new"}, req.Tags)
+    assert.Equal(t, map[string]string{"source": "web"}, req.Labels)
+    assert.Equal(t, "work@example.com", req.ByKind["work"].Email)
+    assert.Equal(t, map[string]interface{}{"comment": "hi", "count": 1}, req.Extra)
+    assert.Equal(t, " Keep@Me ", req.Skipped.Email)
+    assert.Equal(t, " keep ", req.unexported)
+}
+
+func TestNormalizeNotPointer(t *testing.T) {
+    req := normalizeContact{Email: " A@B.C "}
+    assert.NoError(t, Normalize(req))
+    assert.Equal(t, " A@B.C ", req.Email)
+
+    var nilReq *normalizeContact
+    assert.NoError(t, Normalize(nilReq))
+}
+
+type unknownOptionName struct {
+    Name string `normalize:"trim,upper"`
+}
+
+type unknownOptionRequest struct {
+    Names []*unknownOptionName `json:"names"`
+}
+
+func (r *unknownOptionRequest) Validate() *Result {
+    return NewResult()
+}
+
+func TestNormalizeUnknownOption(t *testing.T) {
+    req := unknownOptionRequest{}
+    err := Normalize(&req)
+    require.Error(t, err, "the tag is checked before the field is set")
+    assert.Contains(t, err.Error(), `"upper"`)
+
+    res := Validate(&unknownOptionRequest{Names: []*unknownOptionName{{Name: " anna "}}})
+    assert.False(t, res.IsValid())
+    assert.Contains(t, res.Details, "unknown normalize option")
+}
+
+type normalizeNode struct {
+    Name     string           `json:"name"`
+    Next     *normalizeNode   `json:"next"`
+    Children []*normalizeNode `json:"children"`
+    Extra    []interface{}    `json:"extra"`
+}
+
+func TestNormalizeCycle(t *testing.T) {
+    node := &normalizeNode{Name: " root "}
+    node.Next = node
+    node.Children = []*normalizeNode{node, {Name: " child ", Next: node}}
+    node.Extra = []interface{}{node.Extra}
+    node.Extra[0] = node.Extra
+
+    require.NoError(t, Normalize(node))
+    assert.Equal(t, "root", node.Name)
+    assert.Equal(t, "child", node.Children[1].Name)
+}
@@ -0,0 +1,178 @@
+package validation
+
+import (
+    "errors"
+    "reflect"
+    "strings"
+
+    "github.com/go-playground/validator/v10"
+)
+
+// custom tags registered by NewStructValidator
+const (
+    // TagPassword checks password strength with IsPasswordValid
+    TagPassword = "password"
+    // TagAntiPhishingCode checks the code with IsAntiPhishingCodeValid
+    TagAntiPhishingCode = "anti_phishing_code"
+    // TagCountryCallingCode checks the code matches the country of the sibling field
+    // named by the param, e.g. `validate:"country_calling_code=Country"`
+    TagCountryCallingCode = "country_calling_code"
+    // TagTwoFactorMethod checks the second factor method is one of the methods listed by the param,
+    // e.g. `validate:"two_factor_method=totp"`
+    TagTwoFactorMethod = "two_factor_method"
+)
+
+// StructValidator checks `validate` tags of request structs and reports failures as Result.
+// Errors are named after json tags and codes are stable, so they can be used by FE
+type StructValidator struct {
+    validate *validator.Validate
+    details  map[string]func(fe validator.FieldError) (ErrorDetails, map[string]interface{})
+}
+
+var defaultStructValidator = NewStructValidator()
+
+func NewStructValidator() *StructValidator {
+    v := validator.New()
+    v.RegisterTagNameFunc(jsonFieldName)
+
+    s := &StructValidator{
+        validate: v,
+        details:  make(map[string]func(validator.FieldError) (ErrorDetails, map[string]interface{})),
+    }
+
+    for _, tag := range []string{"required", "required_if", "required_unless", "required_with", "required_without"} {
+        s.details[tag] = withoutData(FieldRequired())
+    }
+    s.details["email"] = withoutData(InvalidEmail())
+    s.details["uuid"] = withoutData(InvalidUUID())
+    s.details["uuid4"] = withoutData(InvalidUUID())
+    s.details["url"] = withoutData(InvalidURL())
+    s.details["uri"] = withoutData(InvalidURL())
+    s.details["min"] = lengthOrValue(TooShort(), TooSmall(), "min")
+    s.details["gte"] = lengthOrValue(TooShort(), TooSmall(), "min")
+    s.details["max"] = lengthOrValue(TooLong(), TooLarge(), "max")
+    s.details["lte"] = lengthOrValue(TooLong(), TooLarge(), "max")
+    s.details["len"] = func(fe validator.FieldError) (ErrorDetails, map[string]interface{}) {
+        return InvalidLength(), map[string]interface{}{"len": fe.Param()}
+    }
+    s.details["oneof"] = func(fe validator.FieldError) (ErrorDetails, map[string]interface{}) {
+        return NotAllowedValue(), map[string]interface{}{"allowed": strings.Fields(fe.Param())}
+    }
+
+    s.mustRegisterTag(TagPassword, func(fl validator.FieldLevel) bool {
+        return IsPasswordValid(fl.Field().String())
+    }, InvalidPassword())
+    s.mustRegisterTag(TagAntiPhishingCode, func(fl validator.FieldLevel) bool {
+        return IsAntiPhishingCodeValid(fl.Field().String())
+    }, InvalidAntiPhishingCode())
+    s.mustRegisterTag(TagCountryCallingCode, isCountryCallingCodeOfField, WrongCountryCallingCode())
+    s.mustRegisterTag(TagTwoFactorMethod, isOneOfParam, Invalid2FAMethod())
+
+    return s
+}
+
+// RegisterTag adds custom validation tag, failures are reported with details
+func (s *StructValidator) RegisterTag(tag string, fn validator.Func, details ErrorDetails) error {
+    if err := s.validate.RegisterValidation(tag, fn); err != nil {
+        return err
+    }
+    s.details[tag] = withoutData(details)
+    return nil
+}
+
+func (s *StructValidator) mustRegisterTag(tag string, fn validator.Func, details ErrorDetails) {
+    if err := s.RegisterTag(tag, fn, details); err != nil {
+        panic(err)
+    }
+}
+
+// Struct validates tags of the struct, v must be a struct or a pointer to it
+func (s *StructValidator) Struct(v interface{}) *Result {
+    out := NewResult()
+
+    err := s.validate.Struct(v)
+    var fieldErrors validator.ValidationErrors
+    if !errors.As(err, &fieldErrors) {
+        if err != nil {
+            out.AddDetails(err.Error())
+        }
+        return out
+    }
+
+    for _, fe := range fieldErrors {
+        details, data := s.fieldErrorDetails(fe)
+        out.AddFieldErrorWithData(fieldPath(fe), details, data, 0)
+    }
+    return out
+}
+
+func (s *StructValidator) fieldErrorDetails(fe validator.FieldError) (ErrorDetails, map[string]interface{}) {
+    if fn, ok := s.details[fe.Tag()]; ok {
+        return fn(fe)
+    }
+    return InvalidValue(), map[string]interface{}{"rule": fe.Tag()}
+}
+
+// fieldPath is json path of the field without the root struct, e.g. contacts[0].email
+func fieldPath(fe validator.FieldError) string {
+    ns := fe.Namespace()
+    if i := strings.IndexByte(ns, '.'); i >= 0 {
+        return ns[i+1:]
+    }
+    return ns
+}
+
+// jsonFieldName names fields after their json tag, query tag is used for query parameters
+func jsonFieldName(f reflect.StructField) string {
+    for _, key := range []string{"json", "query"} {
+        name := strings.SplitN(f.Tag.Get(key), ",", 2)[0]
+        if name == "-" {
+            return ""
+        }
+        if name != "" {
+            return name
+        }
+    }
+    return f.Name
+}
+
+func isCountryCallingCodeOfField(fl validator.FieldLevel) bool {
+    country, _, _, ok := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+    if !ok || country.Kind() != reflect.String {
+        return false
+    }
+    // the country is checked by its own rules
+    if country.String() == "" {
+        return true
+    }
+    return IsCountryCallingCodeValid(country.String(), fl.Field().String())
+}
+
+// isOneOfParam checks the field is one of space separated values of the param
+func isOneOfParam(fl validator.FieldLevel) bool {
+    for _, v := range strings.Fields(fl.Param()) {
+        if fl.Field().String() == v {
+            return true
+        }
+    }
+    return false
+}
+
+func withoutData(details ErrorDetails) func(validator.FieldError) (ErrorDetails, map[string]interface{}) {
+    return func(validator.FieldError) (ErrorDetails, map[string]interface{}) {
+        return details, nil
+    }
+}
+
+// lengthOrValue reports strings and collections as too short or long and numbers as too small or large
+func lengthOrValue(length, value ErrorDetails, param string) func(validator.FieldError) (ErrorDetails, map[string]interface{}) {
+    return func(fe validator.FieldError) (ErrorDetails, map[string]interface{}) {
+        data := map[string]interface{}{param: fe.Param()}
+        switch fe.Kind() {
+        case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+            return length, data
+        default:
+            return value, data
+        }
+    }
+}
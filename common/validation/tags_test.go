@@ -0,0 +1,161 @@
+package validation
+
+import (
+    "testing"
+
+    "github.com/go-playground/validator/v10"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+type tagsContact struct {
+    Email string `json:"email" validate:"required,email"`
+}
+
+type tagsRequest struct {
+    Name               string        `json:"name" validate:"min=2,max=5"`
+    Age                int           `json:"age" validate:"min=18"`
+    Gender             string        `json:"gender" validate:"omitempty,oneof=male female"`
+    Password           string        `json:"password" validate:"password"`
+    Code               string        `json:"anti_phishing_code" validate:"omitempty,anti_phishing_code"`
+    Country            string        `json:"country"`
+    CountryCallingCode string        `json:"country_calling_code" validate:"omitempty,country_calling_code=Country"`
+    Contacts           []tagsContact `json:"contacts" validate:"dive"`
+    Ignored            string        `json:"-" validate:"required"`
+}
+
+func validTagsRequest() tagsRequest {
+    return tagsRequest{
+        Name:               "Anna",
+        Age:                20,
+        Password:           "Passw0rd!",
+        Country:            "Armenia",
+        CountryCallingCode: "374",
+        Contacts:           []tagsContact{{Email: "anna@example.com"}},
+        Ignored:            "set",
+    }
+}
+
+func TestStructValidator(t *testing.T) {
+    testCases := []struct {
+        name     string
+        modify   func(r *tagsRequest)
+        expected map[string][]string
+        data     map[string]map[string]interface{}
+    }{
+        {
+            name:     "valid",
+            modify:   func(*tagsRequest) {},
+            expected: map[string][]string{},
+        },
+        {
+            name:     "string length and number value",
+            modify:   func(r *tagsRequest) { r.Name = "A"; r.Age = 10 },
+            expected: map[string][]string{"name": {"too_short"}, "age": {"too_small"}},
+            data:     map[string]map[string]interface{}{"name": {"min": "2"}, "age": {"min": "18"}},
+        },
+        {
+            name:     "oneof",
+            modify:   func(r *tagsRequest) { r.Gender = "other" },
+            expected: map[string][]string{"gender": {"not_allowed_value"}},
+            data:     map[string]map[string]interface{}{"gender": {"allowed": []string{"male", "female"}}},
+        },
+        {
+            name: "custom tags",
+            modify: func(r *tagsRequest) {
+                r.Password = "weak"
+                r.Code = "!"
+                r.CountryCallingCode = "1"
+            },
+            expected: map[string][]string{
+                "password":             {InvalidPassword().Code},
+                "anti_phishing_code":   {"invalid_anti_phishing_code"},
+                "country_calling_code": {"mismatch_county_code"},
+            },
+        },
+        {
+            name:     "nested field",
+            modify:   func(r *tagsRequest) { r.Contacts = append(r.Contacts, tagsContact{Email: "nope"}) },
+            expected: map[string][]string{"contacts[1].email": {"invalid_email"}},
+        },
+        {
+            name:     "field without json name",
+            modify:   func(r *tagsRequest) { r.Ignored = "" },
+            expected: map[string][]string{"Ignored": {"field_required"}},
+        },
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            req := validTagsRequest()
+            tc.modify(&req)
+
+            res := NewStructValidator().Struct(&req)
+
+            actual := make(map[string][]string)
+            for _, e := range res.Errors {
+                for _, c := range e.Codes {
+                    actual[e.Name] = append(actual[e.Name], c.Code)
+                }
+                if want, ok := tc.data[e.Name]; ok {
+                    assert.Equal(t, want, e.Data, e.Name)
+                }
+            }
+            assert.Equal(t, tc.expected, actual)
+            assert.Equal(t, len(tc.expected) == 0, res.IsValid())
+        })
+    }
+}
+
+func TestStructValidatorRegisterTag(t *testing.T) {
+    s := NewStructValidator()
+    require.NoError(t, s.RegisterTag("alpha_name", func(fl validator.FieldLevel) bool {
+        return IsAlpha(fl.Field().String())
+    }, NotOnlyLetters()))
+
+    req := struct {
+        FirstName string `json:"first_name" validate:"alpha_name"`
+    }{FirstName: "R2D2"}
+
+    res := s.Struct(&req)
+    require.Len(t, res.Errors, 1)
+    assert.Equal(t, "first_name", res.Errors[0].Name)
+    assert.Equal(t, NotOnlyLetters(), res.Errors[0].Codes[0])
+}
+
+type twoFactorRequest struct {
+    Method string `json:"method" validate:"two_factor_method=totp"`
+}
+
+func (r *twoFactorRequest) Validate() *Result {
+    return NewResult()
+}
+
+func TestTwoFactorMethodTag(t *testing.T) {
+    res := Validate(&twoFactorRequest{Method: "sms"})
+    require.Len(t, res.Errors, 1)
+    assert.Equal(t, "method", res.Errors[0].Name)
+    assert.Equal(t, Invalid2FAMethod(), res.Errors[0].Codes[0])
+
+    assert.True(t, Validate(&twoFactorRequest{Method: " totp "}).IsValid())
+}
+
+type mergedRequest struct {
+    Email string `json:"email" validate:"required"`
+}
+
+func (r *mergedRequest) Validate() *Result {
+    out := NewResult()
+    if r.Email == "" {
+        out.AddFieldError(EmailField, EitherPhoneOrEmail())
+    }
+    return out
+}
+
+func TestValidateMergesTagErrors(t *testing.T) {
+    res := Validate(&mergedRequest{Email: "  "})
+
+    require.Len(t, res.Errors, 1)
+    assert.Equal(t, EmailField, res.Errors[0].Name)
+    assert.Equal(t, []ErrorDetails{FieldRequired(), EitherPhoneOrEmail()}, res.Errors[0].Codes)
+}
@@ -23,6 +23,9 @@ type Result struct {
     Errors  []*Error               `json:"errors"`
     Meta    map[string]interface{} `json:"meta,omitempty"`
 
+    // RequestID is filled by httpx.JSONErr to correlate the response with logs
+    RequestID string `json:"request_id,omitempty"`
+
     // used by limit ms to receive events
     Events event.Payload `json:"events,omitempty"`
 }
@@ -100,6 +103,69 @@ func BothEmailAndPhoneProvided() *Result {
     }
 }
 
+func ProfileNotFound() *Result {
+    return &Result{
+        Details: "profile not found",
+        Code:    "profile_not_found",
+    }
+}
+
+func EmailAlreadyVerified() *Result {
+    return &Result{
+        Details: "email is already verified",
+        Code:    "email_already_verified",
+    }
+}
+
+func TwoFactorAlreadyEnabled() *Result {
+    return &Result{
+        Details: "two-factor authentication is already enabled",
+        Code:    "two_factor_already_enabled",
+    }
+}
+
+func TwoFactorNotEnabled() *Result {
+    return &Result{
+        Details: "two-factor authentication is not enabled",
+        Code:    "two_factor_not_enabled",
+    }
+}
+
+func SessionNotFound() *Result {
+    return &Result{
+        Details: "session not found",
+        Code:    "session_not_found",
+    }
+}
+
+func InvalidCredentials() *Result {
+    return &Result{
+        Details: "login or password is wrong",
+        Code:    "invalid_credentials",
+    }
+}
+
+func TwoFactorRequired() *Result {
+    return &Result{
+        Details: "two-factor authentication code is required",
+        Code:    "two_factor_required",
+    }
+}
+
+func InvalidRefreshToken() *Result {
+    return &Result{
+        Details: "refresh token is invalid or expired",
+        Code:    "invalid_refresh_token",
+    }
+}
+
+func TooManyAttempts() *Result {
+    return &Result{
+        Details: "too many sign in attempts, try again later",
+        Code:    "too_many_attempts",
+    }
+}
+
 func CaptchaError(err error) *Result {
     return &Result{
         Details: err.Error(),
@@ -132,6 +198,17 @@ func CodeErrorWithEvents(code string, err error, events event.Payload) *Result {
     }
 }
 
+// Error lets handlers return the result, it is rendered by httpx.HTTPErrorHandler
+func (r *Result) Error() string {
+    if r.Details != "" {
+        return r.Details
+    }
+    if r.Code != "" {
+        return r.Code
+    }
+    return "validation failed"
+}
+
 func (r *Result) IsValid() bool {
     return len(r.Errors) == 0 && r.Details == ""
 }
@@ -229,6 +306,13 @@ func EmptyBirthDate() ErrorDetails {
     }
 }
 
+func InvalidBirthDate() ErrorDetails {
+    return ErrorDetails{
+        Message: "birthday must be in YYYY-MM-DD format",
+        Code:    "invalid_birthday",
+    }
+}
+
 func NotOnlyLetters() ErrorDetails {
     return ErrorDetails{
         Message: "field must contain only letters",
@@ -264,6 +348,13 @@ func InvalidPassword() ErrorDetails {
     }
 }
 
+func WrongPassword() ErrorDetails {
+    return ErrorDetails{
+        Message: "password is wrong",
+        Code:    "wrong_password",
+    }
+}
+
 func RulesNotAccepted() ErrorDetails {
     return ErrorDetails{
         Message: "rules were not accepted",
@@ -362,6 +453,20 @@ func InvalidPageLimit() ErrorDetails {
     }
 }
 
+func InvalidPageOffset() ErrorDetails {
+    return ErrorDetails{
+        Message: "page offset is invalid",
+        Code:    "offset_is_invalid",
+    }
+}
+
+func InvalidCursor() ErrorDetails {
+    return ErrorDetails{
+        Message: "cursor is invalid or doesn't match the order",
+        Code:    "cursor_is_invalid",
+    }
+}
+
 func InvalidOrderColumn() ErrorDetails {
     return ErrorDetails{
         Message: "order column is invalid",
@@ -376,6 +481,20 @@ func InvalidOtpCode() ErrorDetails {
     }
 }
 
+func OtpSentRecently() ErrorDetails {
+    return ErrorDetails{
+        Message: "otp code was sent recently, try again later",
+        Code:    "otp_sent_recently",
+    }
+}
+
+func InvalidGrantType() ErrorDetails {
+    return ErrorDetails{
+        Message: "grant type must be either password or refresh_token",
+        Code:    "invalid_grant_type",
+    }
+}
+
 func InvalidAction() ErrorDetails {
     return ErrorDetails{
         Message: "action is invalid",
@@ -418,9 +537,108 @@ func InvalidConfirmPassword() ErrorDetails {
     }
 }
 
+func InvalidProfileIDs(max int) ErrorDetails {
+    return ErrorDetails{
+        Message: fmt.Sprintf("from 1 to %d profile ids must be provided", max),
+        Code:    "invalid_profile_ids",
+    }
+}
+
+func InvalidProfileID() ErrorDetails {
+    return ErrorDetails{
+        Message: "profile id must be uuid",
+        Code:    "invalid_profile_id",
+    }
+}
+
 func InvalidKeys() ErrorDetails {
     return ErrorDetails{
         Message: "phone or email method is missing",
         Code:    "phone_email_method_missing",
     }
-}
\ No newline at end of file
+}
+
+func UnsupportedLocale() ErrorDetails {
+    return ErrorDetails{
+        Message: "locale is not supported",
+        Code:    "unsupported_locale",
+    }
+}
+
+func VerificationRequired() ErrorDetails {
+    return ErrorDetails{
+        Message: "contact can be changed only by its verification",
+        Code:    "verification_required",
+    }
+}
+
+// generic details reported by StructValidator for standard validation tags
+func FieldRequired() ErrorDetails {
+    return ErrorDetails{
+        Message: "field is required",
+        Code:    "field_required",
+    }
+}
+
+func InvalidUUID() ErrorDetails {
+    return ErrorDetails{
+        Message: "value must be a valid UUID",
+        Code:    "invalid_uuid",
+    }
+}
+
+func InvalidURL() ErrorDetails {
+    return ErrorDetails{
+        Message: "value must be a valid URL",
+        Code:    "invalid_url",
+    }
+}
+
+func TooShort() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is too short",
+        Code:    "too_short",
+    }
+}
+
+func TooLong() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is too long",
+        Code:    "too_long",
+    }
+}
+
+func TooSmall() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is too small",
+        Code:    "too_small",
+    }
+}
+
+func TooLarge() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is too large",
+        Code:    "too_large",
+    }
+}
+
+func InvalidLength() ErrorDetails {
+    return ErrorDetails{
+        Message: "value has invalid length",
+        Code:    "invalid_length",
+    }
+}
+
+func NotAllowedValue() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is not one of allowed",
+        Code:    "not_allowed_value",
+    }
+}
+
+func InvalidValue() ErrorDetails {
+    return ErrorDetails{
+        Message: "value is invalid",
+        Code:    "invalid_value",
+    }
+}
@@ -21,7 +21,7 @@ func ValidateIPAddressAndDevice(ipAddress, device string) *Result {
 
     // Check ip address is valid i.e. IP4 or IP6
     if !IsIpv4Valid(ipAddress) && !IsIpv6Valid(ipAddress) {
-        out.AddFieldError(ipAddressField, InvalidIPAddress(ipAddress))
+        out.AddFieldErrorWithData(ipAddressField, InvalidIPAddress(ipAddress), map[string]interface{}{ipAddressField: ipAddress}, 0)
     }
 
     // Check device is not empty
@@ -37,7 +37,7 @@ func ValidateIPAddress(in string) *Result {
 
     // Check ip address is valid i.e. IP4 or IP6
     if !IsIpv4Valid(in) && !IsIpv6Valid(in) {
-        out.AddFieldError(ipAddressField, InvalidIPAddress(in))
+        out.AddFieldErrorWithData(ipAddressField, InvalidIPAddress(in), map[string]interface{}{ipAddressField: in}, 0)
     }
 
     return out
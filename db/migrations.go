@@ -0,0 +1,11 @@
+// Package db embeds SQL migrations of the service, so the binary can
+// migrate the storage without shipping migration files next to it.
+package db
+
+import "embed"
+
+// MigrationsDir is the root of Migrations file system
+const MigrationsDir = "migrations"
+
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS profiles
+(
+    id                   UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    email                VARCHAR(320),
+    phone                VARCHAR(32),
+    country_calling_code VARCHAR(8),
+    first_name           VARCHAR(255) NOT NULL DEFAULT '',
+    last_name            VARCHAR(255) NOT NULL DEFAULT '',
+    birthdate            DATE,
+    country              VARCHAR(100) NOT NULL DEFAULT '',
+    created_at           TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at           TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS profiles_email_key ON profiles (lower(email)) WHERE email IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS profiles_phone_key ON profiles (country_calling_code, phone) WHERE phone IS NOT NULL;
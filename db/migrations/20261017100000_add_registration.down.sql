@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS credentials;
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS rules_accepted_at;
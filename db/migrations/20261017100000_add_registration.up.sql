@@ -0,0 +1,10 @@
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS rules_accepted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS credentials
+(
+    profile_id    UUID PRIMARY KEY REFERENCES profiles (id) ON DELETE CASCADE,
+    password_hash TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS verification_tokens;
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,16 @@
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS verification_tokens
+(
+    id          UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    profile_id  UUID         NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    purpose     VARCHAR(32)  NOT NULL,
+    destination VARCHAR(320) NOT NULL,
+    token_hash  CHAR(64)     NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ  NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS verification_tokens_profile_id_idx ON verification_tokens (profile_id);
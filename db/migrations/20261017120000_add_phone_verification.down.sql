@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS otp_codes;
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS phone_verified_at;
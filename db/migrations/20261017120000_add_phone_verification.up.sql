@@ -0,0 +1,17 @@
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS otp_codes
+(
+    id          UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    profile_id  UUID        NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    purpose     VARCHAR(32) NOT NULL,
+    destination VARCHAR(40) NOT NULL,
+    code_hash   CHAR(64)    NOT NULL,
+    attempts    INT         NOT NULL DEFAULT 0,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS otp_codes_profile_id_purpose_idx ON otp_codes (profile_id, purpose, created_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE credentials
+    ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    profile_id UUID        NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    action     VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_profile_id_idx ON audit_log (profile_id, created_at DESC);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS two_factor
+(
+    profile_id       UUID PRIMARY KEY REFERENCES profiles (id) ON DELETE CASCADE,
+    method           VARCHAR(16) NOT NULL,
+    secret_encrypted TEXT        NOT NULL,
+    last_used_step   BIGINT      NOT NULL DEFAULT 0,
+    confirmed_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    profile_id UUID        NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    code_hash  CHAR(64)    NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (profile_id, code_hash)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS anti_phishing_codes;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS anti_phishing_codes
+(
+    profile_id     UUID PRIMARY KEY REFERENCES profiles (id) ON DELETE CASCADE,
+    code_encrypted TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
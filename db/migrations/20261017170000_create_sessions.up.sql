@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id            UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    profile_id    UUID         NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    device        VARCHAR(255) NOT NULL,
+    ip_address    VARCHAR(45)  NOT NULL,
+    user_agent    TEXT         NOT NULL DEFAULT '',
+    location      VARCHAR(128) NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    last_seen_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (profile_id, device)
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    id                    VARCHAR(64) PRIMARY KEY,
+    algorithm             VARCHAR(16) NOT NULL,
+    private_key_encrypted TEXT        NOT NULL,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS signing_keys_created_at_idx ON signing_keys (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    profile_id UUID        NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+    session_id UUID REFERENCES sessions (id) ON DELETE SET NULL,
+    family_id  UUID        NOT NULL,
+    token_hash CHAR(64)    NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_profile_id_idx ON refresh_tokens (profile_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
@@ -0,0 +1,2 @@
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
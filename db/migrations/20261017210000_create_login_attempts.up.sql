@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_attempts
+(
+    key               VARCHAR(320) PRIMARY KEY,
+    attempts          INT         NOT NULL,
+    window_started_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/storage"
+)
+
+type antiPhishingCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *antiPhishingCodeRequest) Validate() *validation.Result {
+	return validation.ValidateAntiPhishingCode(r.Code)
+}
+
+type antiPhishingCodeResponse struct {
+	// Code is null if the user hasn't set it
+	Code *string `json:"code"`
+}
+
+// GetAntiPhishingCode godoc
+// @Summary Get anti-phishing code
+// @Description Returns the code included into emails sent to the authenticated user
+// @Tags anti-phishing
+// @Produce json
+// @Success 200 {object} antiPhishingCodeResponse
+// @Failure 401 {object} validation.Result
+// @Router /profile/anti-phishing-code [get]
+func (h *Handler) GetAntiPhishingCode(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	return h.respondAntiPhishingCode(c, userID)
+}
+
+// PutAntiPhishingCode godoc
+// @Summary Set anti-phishing code
+// @Description Sets or replaces the code included into emails, it must be 4-20 letters or digits
+// @Tags anti-phishing
+// @Accept json
+// @Produce json
+// @Param request body antiPhishingCodeRequest true "code"
+// @Success 200 {object} antiPhishingCodeResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile/anti-phishing-code [put]
+func (h *Handler) PutAntiPhishingCode(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req antiPhishingCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err := h.ss.Profiles.Get(ctx, userID); err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	encrypted, err := h.cipher.Encrypt([]byte(req.Code))
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	err = h.ss.AntiPhishingCodes.Save(ctx, &storage.AntiPhishingCode{
+		ProfileID:     userID,
+		CodeEncrypted: encrypted,
+	})
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditAntiPhishingCodeSet)
+
+	return c.JSON(http.StatusOK, antiPhishingCodeResponse{Code: &req.Code})
+}
+
+// DeleteAntiPhishingCode godoc
+// @Summary Remove anti-phishing code
+// @Tags anti-phishing
+// @Success 204
+// @Failure 401 {object} validation.Result
+// @Router /profile/anti-phishing-code [delete]
+func (h *Handler) DeleteAntiPhishingCode(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	err = h.ss.AntiPhishingCodes.Delete(c.Request().Context(), userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.NoContent(http.StatusNoContent)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditAntiPhishingCodeRemoved)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetProfileAntiPhishingCode godoc
+// @Summary Get anti-phishing code of the profile
+// @Description For services sending emails on behalf of the user
+// @Tags internal
+// @Produce json
+// @Param id path string true "profile id"
+// @Success 200 {object} antiPhishingCodeResponse
+// @Failure 401
+// @Failure 404 {object} validation.Result
+// @Security BasicAuth
+// @Router /profiles/{id}/anti-phishing-code [get]
+func (h *Handler) GetProfileAntiPhishingCode(c echo.Context) error {
+	p, err := h.profileByParam(c)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return h.respondAntiPhishingCode(c, p.ID)
+}
+
+func (h *Handler) respondAntiPhishingCode(c echo.Context, profileID string) error {
+	code, err := h.antiPhishingCode(c.Request().Context(), profileID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	var res antiPhishingCodeResponse
+	if code != "" {
+		res.Code = &code
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// antiPhishingCode returns decrypted code of the profile, empty if it is not set
+func (h *Handler) antiPhishingCode(ctx context.Context, profileID string) (string, error) {
+	apc, err := h.ss.AntiPhishingCodes.Get(ctx, profileID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	code, err := h.cipher.Decrypt(apc.CodeEncrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(code), nil
+}
+
+// securityMailData adds anti-phishing code of the profile to the email data,
+// the email is still sent without it if the code can't be loaded
+func (h *Handler) securityMailData(ctx context.Context, profileID string, data mail.Data) mail.Data {
+	code, err := h.antiPhishingCode(ctx, profileID)
+	if err != nil {
+		h.logger.WithContext(ctx).Errorf("failed to load anti-phishing code of %s: %s", profileID, err)
+	}
+	data.AntiPhishingCode = code
+	return data
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+	"github.com/levongh/profile/internal/token"
+)
+
+const (
+	grantTypePassword     = "password"
+	grantTypeRefreshToken = "refresh_token"
+
+	fieldGrantType    = "grant_type"
+	fieldRefreshToken = "refresh_token"
+
+	tokenTypeBearer = "Bearer"
+
+	// prefixes of attempt counter keys, see attemptKey
+	attemptKeyLogin   = "login:"
+	attemptKeyIP      = "ip:"
+	attemptKeyProfile = "profile:"
+	attemptKeyReset   = "reset:"
+)
+
+type tokenRequest struct {
+	GrantType string `json:"grant_type"`
+
+	// password grant, either email or phone with its calling code
+	Email              string `json:"email" normalize:"trim,lower"`
+	Phone              string `json:"phone"`
+	CountryCallingCode string `json:"country_calling_code"`
+	Password           string `json:"password" normalize:"-"`
+	// Code is TOTP or recovery code, required if two-factor authentication is enabled
+	Code string `json:"code"`
+
+	// refresh_token grant
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r *tokenRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+
+	switch r.GrantType {
+	case grantTypePassword:
+		if r.Email == "" && r.Phone == "" {
+			out.AddFieldError(validation.EmailField, validation.EitherPhoneOrEmail())
+		}
+		// the same rules as on registration, so malformed logins aren't looked up
+		var email, phone *string
+		if r.Email != "" {
+			email = &r.Email
+		}
+		if r.Phone != "" {
+			phone = &r.Phone
+		}
+		validateContacts(out, email, phone, &r.CountryCallingCode, "")
+		if r.Password == "" {
+			out.AddFieldError(fieldPassword, validation.EmptyPassword())
+		}
+	case grantTypeRefreshToken:
+		if r.RefreshToken == "" {
+			out.AddFieldError(fieldRefreshToken, validation.EmptyRefreshToken())
+		}
+	default:
+		out.AddFieldError(fieldGrantType, validation.InvalidGrantType())
+	}
+
+	return out
+}
+
+// login identifies the account of the password grant even if it doesn't exist
+func (r *tokenRequest) login() string {
+	if r.Email != "" {
+		return r.Email
+	}
+	return r.CountryCallingCode + r.Phone
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn is lifetime of the access token in seconds
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newTokenResponse(pair *token.Pair) tokenResponse {
+	return tokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int(pair.ExpiresIn.Seconds()),
+		RefreshToken: pair.RefreshToken,
+	}
+}
+
+type revokeTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r *revokeTokenRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.RefreshToken == "" {
+		out.AddFieldError(fieldRefreshToken, validation.EmptyRefreshToken())
+	}
+	return out
+}
+
+// Token godoc
+// @Summary Issue tokens
+// @Description Signs in with password (and second factor if enabled) or rotates refresh token.
+// @Description Presenting already rotated refresh token revokes all tokens issued from the same sign in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Device-Id header string false "client installation id, binds tokens to the device session"
+// @Param request body tokenRequest true "grant"
+// @Success 200 {object} tokenResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /auth/token [post]
+func (h *Handler) Token(c echo.Context) error {
+	var req tokenRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	if req.GrantType == grantTypeRefreshToken {
+		return h.refreshToken(c, req.RefreshToken)
+	}
+	return h.passwordToken(c, &req)
+}
+
+func (h *Handler) passwordToken(c echo.Context, req *tokenRequest) error {
+	ctx := c.Request().Context()
+
+	loginKey := attemptKey(attemptKeyLogin, req.login())
+	ok, err := h.countAttempt(ctx,
+		attemptLimit{key: loginKey, max: h.cfg.LoginMaxAttempts},
+		attemptLimit{key: attemptKey(attemptKeyIP, c.RealIP()), max: h.cfg.LoginIPMaxAttempts},
+	)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !ok {
+		return tooManyAttempts(c)
+	}
+
+	p, err := h.findByLogin(ctx, req)
+	if errors.Is(err, storage.ErrNotFound) {
+		// takes as long as checking the password, so timing doesn't reveal whether the account exists
+		_, _ = h.hasher.Hash(req.Password)
+		return httpx.JSONErr(c, err, http.StatusUnauthorized, validation.InvalidCredentials())
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	cred, err := h.ss.Credentials.Get(ctx, p.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		_, _ = h.hasher.Hash(req.Password)
+		return httpx.JSONErr(c, err, http.StatusUnauthorized, validation.InvalidCredentials())
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	ok, err = h.verifyPassword(ctx, cred, req.Password)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		return httpx.JSONErr(c, nil, http.StatusUnauthorized, validation.InvalidCredentials())
+	}
+
+	tf, err := h.enabledTwoFactor(ctx, p.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if tf != nil {
+		if req.Code == "" {
+			return httpx.JSONErr(c, nil, http.StatusUnauthorized, validation.TwoFactorRequired())
+		}
+		ok, err := h.verifySecondFactor(c, tf, req.Code)
+		if err != nil {
+			return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+		}
+		if !ok {
+			return invalidSecondFactor(c)
+		}
+	}
+
+	pair, err := h.tokens.Issue(ctx, p.ID, h.startSession(c, p.ID))
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	h.audit(c, p.ID, storage.AuditSignedIn)
+	// attempts of the client IP are kept, they are limited regardless of the outcome
+	if err := h.ss.LoginAttempts.Reset(ctx, loginKey); err != nil {
+		h.logger.WithContext(ctx).Errorf("failed to reset sign in attempts of %s: %s", p.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, newTokenResponse(pair))
+}
+
+// attemptKey identifies the attempt counter of the value, it is hashed
+// so keys of client supplied values fit the storage
+func attemptKey(prefix, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+type attemptLimit struct {
+	key string
+	max int
+}
+
+// countAttempt counts the attempt against every limit before the secret is checked,
+// so parallel requests can't exceed them, false is returned if any limit is exceeded
+func (h *Handler) countAttempt(ctx context.Context, limits ...attemptLimit) (bool, error) {
+	ok := true
+	for _, l := range limits {
+		attempts, err := h.ss.LoginAttempts.Increment(ctx, l.key, h.cfg.LoginAttemptWindow)
+		if err != nil {
+			return false, err
+		}
+		if attempts > l.max {
+			ok = false
+		}
+	}
+	return ok, nil
+}
+
+func tooManyAttempts(c echo.Context) error {
+	return httpx.JSONErr(c, nil, http.StatusTooManyRequests, validation.TooManyAttempts())
+}
+
+// findByLogin looks up the profile by email or phone of the token request
+func (h *Handler) findByLogin(ctx context.Context, req *tokenRequest) (*storage.Profile, error) {
+	if req.Email != "" {
+		return h.ss.Profiles.FindByEmail(ctx, req.Email)
+	}
+	return h.ss.Profiles.FindByPhone(ctx, req.CountryCallingCode, req.Phone)
+}
+
+func (h *Handler) refreshToken(c echo.Context, refreshToken string) error {
+	pair, err := h.tokens.Refresh(c.Request().Context(), refreshToken)
+	if errors.Is(err, token.ErrInvalidRefreshToken) || errors.Is(err, token.ErrRefreshTokenReused) {
+		return httpx.JSONErr(c, err, http.StatusUnauthorized, validation.InvalidRefreshToken())
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, newTokenResponse(pair))
+}
+
+// RevokeToken godoc
+// @Summary Revoke refresh token
+// @Description Signs out: the token and all tokens rotated from the same sign in stop working
+// @Tags auth
+// @Accept json
+// @Param request body revokeTokenRequest true "refresh token"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Router /auth/revoke [post]
+func (h *Handler) RevokeToken(c echo.Context) error {
+	var req revokeTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	if err := h.tokens.Revoke(c.Request().Context(), req.RefreshToken); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// JWKS serves public keys the API gateway verifies access tokens with,
+// it is outside of /api/v1 so it isn't part of the swagger docs
+func (h *Handler) JWKS(c echo.Context) error {
+	jwks, err := h.tokens.JWKS(c.Request().Context())
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	// keys are published token.JWKSMaxAge before signing with them, so cached JWKS is never behind
+	c.Response().Header().Set(echo.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(token.JWKSMaxAge.Seconds())))
+	return c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRequestValidation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid email",
+			body:       `{"grant_type":"password","email":"local@profile.local","password":"Local-pa55word"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "too long email",
+			body:       fmt.Sprintf(`{"grant_type":"password","email":"%s@profile.local","password":"x"}`, strings.Repeat("a", 400)),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed email",
+			body:       `{"grant_type":"password","email":"local","password":"x"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "phone with letters",
+			body:       `{"grant_type":"password","phone":"555-01","country_calling_code":"1","password":"x"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "phone without calling code",
+			body:       `{"grant_type":"password","phone":"5550100","password":"x"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown phone",
+			body:       `{"grant_type":"password","phone":"5550100","country_calling_code":"1","password":"x"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	s := newTestServer(t)
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, status := requestToken(t, s, tc.body)
+			assert.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
+
+func TestPasswordTokenLimited(t *testing.T) {
+	s := newTestServer(t)
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	password := func(email, password string) int {
+		_, status := requestToken(t, s, fmt.Sprintf(`{"grant_type":"password","email":%q,"password":%q}`, email, password))
+		return status
+	}
+
+	for i := 0; i < s.cfg.LoginMaxAttempts; i++ {
+		require.Equal(t, http.StatusUnauthorized, password(s.cfg.MockUserEmail, "Wrong-pa55word"))
+		require.Equal(t, http.StatusUnauthorized, password("unknown@profile.local", "Wrong-pa55word"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, password(s.cfg.MockUserEmail, s.cfg.MockUserPassword), "correct password is limited too")
+	assert.Equal(t, http.StatusTooManyRequests, password("unknown@profile.local", "Wrong-pa55word"), "unknown accounts are limited the same way")
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s := newTestServer(t)
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	first := signIn(t, s, s.cfg.MockUserPassword)
+
+	second, status := refresh(t, s, first.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+
+	_, status = refresh(t, s, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status, "rotated token is reused")
+	_, status = refresh(t, s, second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status, "tokens of the same sign in are revoked")
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/levongh/profile/common/httpx"
+)
+
+// backgroundTasks runs work which outlives the request that started it, Server.Shutdown waits for it
+type backgroundTasks struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newBackgroundTasks() *backgroundTasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundTasks{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn with context carrying the request id of reqCtx, limited by timeout
+func (t *backgroundTasks) Go(reqCtx context.Context, timeout time.Duration, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(httpx.WithRequestID(t.ctx, httpx.GetRequestID(reqCtx)), timeout)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// Wait waits for running tasks until ctx is done, then cancels them and returns ctx error
+func (t *backgroundTasks) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
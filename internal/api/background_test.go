@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/levongh/profile/common/httpx"
+)
+
+func TestBackgroundTasks(t *testing.T) {
+	tasks := newBackgroundTasks()
+	reqCtx := httpx.WithRequestID(context.Background(), "req-1")
+
+	var reqID string
+	tasks.Go(reqCtx, time.Second, func(ctx context.Context) {
+		reqID = httpx.GetRequestID(ctx)
+	})
+	assert.NoError(t, tasks.Wait(context.Background()))
+	assert.Equal(t, "req-1", reqID)
+
+	cancelled := make(chan error, 1)
+	tasks.Go(reqCtx, time.Minute, func(ctx context.Context) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tasks.Wait(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, <-cancelled, context.Canceled, "running tasks are cancelled once shutdown gives up")
+}
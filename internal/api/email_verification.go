@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	fieldToken = "token"
+
+	verifyEmailPath = "/verify-email"
+)
+
+// requestEmailVerificationRequest changes the email once the link sent to it is confirmed,
+// the stored email is verified if it is empty
+type requestEmailVerificationRequest struct {
+	Email string `json:"email" normalize:"trim,lower"`
+}
+
+func (r *requestEmailVerificationRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Email != "" && !validation.IsEmailValid(r.Email) {
+		out.AddFieldError(validation.EmailField, validation.InvalidEmail())
+	}
+	return out
+}
+
+type confirmEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (r *confirmEmailRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Token == "" {
+		out.AddFieldError(fieldToken, validation.InvalidCode())
+	}
+	return out
+}
+
+// RequestEmailVerification godoc
+// @Summary Request email verification
+// @Description Sends a single-use link to the profile email or to the new email given in the request,
+// @Description the token from the link is confirmed by ConfirmEmailVerification which changes the email
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body requestEmailVerificationRequest false "new email"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Router /profile/email/verification [post]
+func (h *Handler) RequestEmailVerification(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req requestEmailVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	p, err := h.ss.Profiles.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	email := req.Email
+	if email == "" {
+		if p.Email == nil {
+			res := validation.NewResult().AddFieldError(validation.EmailField, validation.InvalidEmail())
+			return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+		}
+		email = *p.Email
+	}
+
+	if p.Email != nil && strings.EqualFold(*p.Email, email) {
+		if p.EmailVerifiedAt != nil {
+			return httpx.JSONErr(c, nil, http.StatusConflict, validation.EmailAlreadyVerified())
+		}
+	} else {
+		other, err := h.ss.Profiles.FindByEmail(ctx, email)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+		}
+		if other != nil {
+			res := validation.NewResult().AddFieldError(validation.EmailField, validation.UserAlreadyExists())
+			return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+		}
+	}
+
+	token, hash, err := secret.NewToken()
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	err = h.ss.VerificationTokens.Create(ctx, &storage.VerificationToken{
+		ProfileID:   p.ID,
+		Purpose:     storage.PurposeEmailVerification,
+		Destination: email,
+		TokenHash:   hash,
+		ExpiresAt:   time.Now().Add(h.cfg.EmailVerificationTTL),
+	})
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	msg, err := mail.VerifyEmail.Render(email, h.securityMailData(ctx, p.ID, mail.Data{
+		Link:      h.clientLink(verifyEmailPath, url.Values{fieldToken: {token}}),
+		ExpiresIn: h.cfg.EmailVerificationTTL,
+	}))
+	if err == nil {
+		err = h.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ConfirmEmailVerification godoc
+// @Summary Confirm email verification
+// @Description Marks the email as verified using the token sent by RequestEmailVerification, the email is
+// @Description changed to the one the token was sent to. The profile isn't returned as anyone having the link can call it
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body confirmEmailRequest true "token"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Router /email/verification/confirm [post]
+func (h *Handler) ConfirmEmailVerification(c echo.Context) error {
+	var req confirmEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+	invalidToken := validation.NewResult().AddFieldError(fieldToken, validation.InvalidCode())
+
+	t, err := h.ss.VerificationTokens.Consume(ctx, storage.PurposeEmailVerification, secret.HashToken(req.Token))
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, invalidToken)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	p, err := h.ss.Profiles.Get(ctx, t.ProfileID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, invalidToken)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	if p.Email != nil && strings.EqualFold(*p.Email, t.Destination) {
+		if p.EmailVerifiedAt != nil {
+			return c.NoContent(http.StatusNoContent)
+		}
+	} else if p.EmailVerifiedAt != nil && t.CreatedAt.Before(*p.EmailVerifiedAt) {
+		// email was changed or verified after the token had been sent
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, invalidToken)
+	}
+
+	now := time.Now().UTC()
+	_, err = h.ss.Profiles.Update(ctx, p.ID, storage.ProfileUpdate{Email: &t.Destination, EmailVerifiedAt: &now})
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return contactTakenErr(c, err)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, invalidToken)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// clientLink builds a link to the frontend page
+func (h *Handler) clientLink(path string, query url.Values) string {
+	return h.cfg.ClientHost + path + "?" + query.Encode()
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/storage"
+)
+
+func TestConfirmEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	mailer := s.handler.mailer.(*fakeMailer)
+	email, phone := "new@profile.local", "5550100"
+	require.NoError(t, s.ss.Profiles.Save(ctx, &storage.Profile{ID: testMockUserID, Email: &email, Phone: &phone}))
+
+	c, rec := newUserContext(s, http.MethodPost, "")
+	require.NoError(t, s.handler.RequestEmailVerification(c))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, mailer.sent, 1)
+	match := linkTokenPattern.FindStringSubmatch(mailer.sent[0].Body)
+	require.Len(t, match, 2)
+
+	confirm := func() (int, string) {
+		c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"token":%q}`, match[1]))
+		require.NoError(t, s.handler.ConfirmEmailVerification(c))
+		return rec.Code, rec.Body.String()
+	}
+	status, body := confirm()
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Empty(t, body, "the profile isn't returned to whoever has the link")
+
+	p, err := s.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.NotNil(t, p.EmailVerifiedAt)
+
+	status, _ = confirm()
+	assert.Equal(t, http.StatusBadRequest, status, "the link is single-use")
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	mailer := s.handler.mailer.(*fakeMailer)
+	saveTestProfile(t, s)
+	taken := "taken@profile.local"
+	require.NoError(t, s.ss.Profiles.Save(ctx, &storage.Profile{ID: "7d4c1f2e-3b5a-4c6d-8e9f-0a1b2c3d4e5f", Email: &taken}))
+
+	request := func(email string) (int, string) {
+		c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"email":%q}`, email))
+		require.NoError(t, s.handler.RequestEmailVerification(c))
+		if rec.Code != http.StatusNoContent {
+			return rec.Code, ""
+		}
+		msg := mailer.sent[len(mailer.sent)-1]
+		assert.Equal(t, email, msg.To, "the link is sent to the new email")
+		return rec.Code, linkTokenPattern.FindStringSubmatch(msg.Body)[1]
+	}
+	confirm := func(token string) int {
+		c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"token":%q}`, token))
+		require.NoError(t, s.handler.ConfirmEmailVerification(c))
+		return rec.Code
+	}
+
+	status, _ := request("Taken@Profile.local")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = request("anna@profile.local")
+	assert.Equal(t, http.StatusConflict, status, "the stored email is verified already")
+
+	status, stale := request("first@profile.local")
+	require.Equal(t, http.StatusNoContent, status)
+	status, token := request("second@profile.local")
+	require.Equal(t, http.StatusNoContent, status)
+
+	p, err := s.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "anna@profile.local", *p.Email, "the email is kept until the link is confirmed")
+
+	require.Equal(t, http.StatusNoContent, confirm(token))
+	p, err = s.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "second@profile.local", *p.Email)
+	assert.NotNil(t, p.EmailVerifiedAt)
+
+	assert.Equal(t, http.StatusBadRequest, confirm(stale), "the link sent before the change doesn't work")
+	p, err = s.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "second@profile.local", *p.Email)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+
+	"github.com/levongh/profile/internal/config"
+	"github.com/levongh/profile/internal/health"
+	"github.com/levongh/profile/internal/sms"
+	"github.com/levongh/profile/internal/storage"
+)
+
+var errShuttingDown = errors.New("shutting down")
+
+// newHealthRegistry checks postgres for readiness, providers are checked too
+// but their failures only affect the requests that use them
+func (s *Server) newHealthRegistry(cfg *config.Config, ss *storage.ServiceStorage) *health.Registry {
+	r := health.NewRegistry(cfg.HealthCacheTTL, cfg.HealthCheckTimeout)
+
+	if ss.DB() != nil {
+		r.Register("postgres", health.CheckerFunc(ss.Ping))
+	}
+
+	r.RegisterOptional("smtp", health.Dial(net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))))
+	if cfg.SMSProvider == sms.ProviderHTTP {
+		if addr, err := urlHostPort(cfg.SMSProviderURL); err == nil {
+			r.RegisterOptional("sms", health.Dial(addr))
+		}
+	}
+	if checker := tracerReporterChecker(); checker != nil {
+		r.RegisterOptional("tracer", checker)
+	}
+
+	return r
+}
+
+// tracerReporterChecker checks the endpoint spans are reported to, jaeger is configured
+// from the environment the same way jaegertracing does it. It is nil if tracing is disabled
+func tracerReporterChecker() health.Checker {
+	cfg, err := jaegercfg.FromEnv()
+	if err != nil || cfg.Disabled || cfg.Reporter == nil {
+		return nil
+	}
+
+	if cfg.Reporter.CollectorEndpoint != "" {
+		addr, err := urlHostPort(cfg.Reporter.CollectorEndpoint)
+		if err != nil {
+			return nil
+		}
+		return health.Dial(addr)
+	}
+
+	// agent receives spans over UDP, so only its name can be checked
+	host, _, err := net.SplitHostPort(cfg.Reporter.LocalAgentHostPort)
+	if err != nil {
+		return nil
+	}
+	return health.Resolve(host)
+}
+
+// urlHostPort returns address to dial for the URL, the port defaults to the scheme one
+func urlHostPort(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// livez reports the process is able to serve requests, dependencies are not checked
+// so a restart isn't triggered by their outage
+func (s *Server) livez(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// readyz reports whether the instance should receive traffic, it is public so only the status is returned
+func (s *Server) readyz(c echo.Context) error {
+	report := s.readiness()
+	return c.JSON(readyzStatus(&report), map[string]string{"status": report.Status})
+}
+
+// readyzDetails is readyz with a breakdown per dependency including errors of failed checks
+func (s *Server) readyzDetails(c echo.Context) error {
+	report := s.readiness()
+	return c.JSON(readyzStatus(&report), report)
+}
+
+// readiness fails as soon as Shutdown is called, the cached report of dependencies isn't consulted then
+func (s *Server) readiness() health.Report {
+	if s.IsShuttingDown() {
+		return health.Report{
+			Status:    health.StatusFail,
+			CheckedAt: time.Now(),
+			Checks:    map[string]health.Result{"server": {Status: health.StatusFail, Error: errShuttingDown.Error()}},
+		}
+	}
+	return s.health.Check()
+}
+
+func readyzStatus(report *health.Report) int {
+	if !report.OK() {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
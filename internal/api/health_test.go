@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/health"
+)
+
+func TestReadyz(t *testing.T) {
+	testCases := []struct {
+		name     string
+		handler  func(s *Server) echo.HandlerFunc
+		wantBody string
+	}{
+		{
+			name:     "public status only",
+			handler:  func(s *Server) echo.HandlerFunc { return s.readyz },
+			wantBody: `{"status":"fail"}`,
+		},
+		{
+			name:     "internal details",
+			handler:  func(s *Server) echo.HandlerFunc { return s.readyzDetails },
+			wantBody: `"error":"pq: password authentication failed for user \"profile\""`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.health = health.NewRegistry(time.Second, time.Second)
+			s.health.Register("postgres", health.CheckerFunc(func(context.Context) error {
+				return errors.New(`pq: password authentication failed for user "profile"`)
+			}))
+
+			rec := httptest.NewRecorder()
+			c := s.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)
+			require.NoError(t, tc.handler(s)(c))
+
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestReadyzShuttingDown(t *testing.T) {
+	s := newTestServer(t)
+	s.health = health.NewRegistry(time.Hour, time.Second)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		require.NoError(t, s.readyz(s.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)))
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, readyz())
+
+	atomic.StoreInt32(&s.shuttingDown, 1)
+	assert.Equal(t, http.StatusServiceUnavailable, readyz(), "cached report is not used")
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	fieldIDs = "ids"
+
+	// maxBulkProfiles limits the number of ids in a single bulk request
+	maxBulkProfiles = 100
+)
+
+type lookupProfileRequest struct {
+	Email              string `query:"email" normalize:"trim,lower"`
+	Phone              string `query:"phone"`
+	CountryCallingCode string `query:"country_calling_code"`
+}
+
+func (r *lookupProfileRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Email == "" && r.Phone == "" {
+		out.AddFieldError(validation.EmailField, validation.EitherPhoneOrEmail())
+		return out
+	}
+	// phone is looked up only along with its calling code
+	if r.Email == "" {
+		validateContacts(out, nil, &r.Phone, &r.CountryCallingCode, "")
+	}
+	return out
+}
+
+type bulkProfilesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (r *bulkProfilesRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if len(r.IDs) == 0 || len(r.IDs) > maxBulkProfiles {
+		out.AddFieldErrorWithData(fieldIDs, validation.InvalidProfileIDs(maxBulkProfiles),
+			map[string]interface{}{"max": maxBulkProfiles}, 0)
+		return out
+	}
+	for i, id := range r.IDs {
+		if _, err := uuid.Parse(id); err != nil {
+			out.AddFieldErrorWithData(fieldIDs, validation.InvalidProfileID(), map[string]interface{}{"id": id}, i)
+		}
+	}
+	return out
+}
+
+type bulkProfilesResponse struct {
+	// Data contains found profiles only, unknown ids are skipped
+	Data []profileResponse `json:"data"`
+}
+
+type verificationStatusResponse struct {
+	EmailVerified    bool       `json:"email_verified"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	PhoneVerified    bool       `json:"phone_verified"`
+	PhoneVerifiedAt  *time.Time `json:"phone_verified_at"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+}
+
+// GetProfileByID godoc
+// @Summary Get profile by id
+// @Tags internal
+// @Produce json
+// @Param id path string true "profile id"
+// @Success 200 {object} profileResponse
+// @Failure 401
+// @Failure 404 {object} validation.Result
+// @Security BasicAuth
+// @Router /profiles/{id} [get]
+func (h *Handler) GetProfileByID(c echo.Context) error {
+	p, err := h.profileByParam(c)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newProfileResponse(p))
+}
+
+// LookupProfile godoc
+// @Summary Look up profile by email or phone
+// @Description Email is matched case-insensitively, phone requires its country calling code
+// @Tags internal
+// @Produce json
+// @Param email query string false "email"
+// @Param phone query string false "phone without calling code"
+// @Param country_calling_code query string false "country calling code of the phone"
+// @Success 200 {object} profileResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401
+// @Failure 404 {object} validation.Result
+// @Security BasicAuth
+// @Router /profiles/lookup [get]
+func (h *Handler) LookupProfile(c echo.Context) error {
+	var req lookupProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	var (
+		p   *storage.Profile
+		err error
+	)
+	if req.Email != "" {
+		p, err = h.ss.Profiles.FindByEmail(ctx, req.Email)
+	} else {
+		p, err = h.ss.Profiles.FindByPhone(ctx, req.CountryCallingCode, req.Phone)
+	}
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newProfileResponse(p))
+}
+
+// BulkProfiles godoc
+// @Summary Get profiles by ids
+// @Description Returns up to 100 profiles, unknown ids are skipped
+// @Tags internal
+// @Accept json
+// @Produce json
+// @Param request body bulkProfilesRequest true "profile ids"
+// @Success 200 {object} bulkProfilesResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401
+// @Security BasicAuth
+// @Router /profiles/bulk [post]
+func (h *Handler) BulkProfiles(c echo.Context) error {
+	var req bulkProfilesRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	profiles, err := h.ss.Profiles.GetMany(c.Request().Context(), req.IDs)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	res := bulkProfilesResponse{Data: make([]profileResponse, 0, len(profiles))}
+	for i := range profiles {
+		res.Data = append(res.Data, newProfileResponse(&profiles[i]))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetVerificationStatus godoc
+// @Summary Get verification status of the profile
+// @Tags internal
+// @Produce json
+// @Param id path string true "profile id"
+// @Success 200 {object} verificationStatusResponse
+// @Failure 401
+// @Failure 404 {object} validation.Result
+// @Security BasicAuth
+// @Router /profiles/{id}/verification [get]
+func (h *Handler) GetVerificationStatus(c echo.Context) error {
+	p, err := h.profileByParam(c)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	_, err = h.enabledTwoFactor(c.Request().Context(), p.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.JSON(http.StatusOK, verificationStatusResponse{
+		EmailVerified:    p.EmailVerifiedAt != nil,
+		EmailVerifiedAt:  p.EmailVerifiedAt,
+		PhoneVerified:    p.PhoneVerifiedAt != nil,
+		PhoneVerifiedAt:  p.PhoneVerifiedAt,
+		TwoFactorEnabled: err == nil,
+	})
+}
+
+// profileByParam loads the profile of id path parameter, malformed ids are reported as not found
+func (h *Handler) profileByParam(c echo.Context) (*storage.Profile, error) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, storage.ErrNotFound
+	}
+	return h.ss.Profiles.Get(c.Request().Context(), id)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/storage"
+)
+
+func TestLookupProfile(t *testing.T) {
+	s := newTestServer(t)
+	email, phone, callingCode := "anna@profile.local", "5550100", "1"
+	require.NoError(t, s.ss.Profiles.Save(context.Background(), &storage.Profile{
+		ID: testMockUserID, Email: &email, Phone: &phone, CountryCallingCode: &callingCode,
+	}))
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "email", query: "email=Anna@Profile.local", expected: http.StatusOK},
+		{name: "phone", query: "phone=5550100&country_calling_code=1", expected: http.StatusOK},
+		{name: "phone without calling code", query: "phone=5550100", expected: http.StatusBadRequest},
+		{name: "malformed phone", query: "phone=555-0100&country_calling_code=1", expected: http.StatusBadRequest},
+		{name: "unknown phone", query: "phone=5550199&country_calling_code=1", expected: http.StatusNotFound},
+		{name: "no contacts", expected: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := s.NewContext(httptest.NewRequest(http.MethodGet, "/profiles/lookup?"+tc.query, nil), rec)
+			require.NoError(t, s.handler.LookupProfile(c))
+			assert.Equal(t, tc.expected, rec.Code, rec.Body.String())
+		})
+	}
+}
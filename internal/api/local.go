@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/internal/storage"
+)
+
+// mockAuthMiddleware stands in for the API gateway in local mode, requests are made on behalf
+// of the user from MockUserHeader or the configured mock user
+func (s *Server) mockAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID := strings.TrimSpace(c.Request().Header.Get(s.cfg.MockUserHeader))
+		if userID == "" {
+			userID = s.cfg.MockUserID
+		}
+		// profile ids are uuids, anything else can't identify a user
+		if _, err := uuid.Parse(userID); err != nil {
+			return unauthorized(c, httpx.ErrUnauthorized)
+		}
+
+		c.Set(httpx.ContextKeyUserID.String(), userID)
+		ctx := context.WithValue(c.Request().Context(), httpx.ContextKeyUserID, userID)
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// localCORS lets the frontend served from CLIENT_HOST call the API directly,
+// outside of local mode CORS is handled by the API gateway
+func (s *Server) localCORS() echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: []string{s.cfg.ClientHost},
+		AllowHeaders: []string{
+			echo.HeaderOrigin,
+			echo.HeaderContentType,
+			echo.HeaderAccept,
+			echo.HeaderAuthorization,
+			s.cfg.MockUserHeader,
+			headerDeviceID,
+		},
+		ExposeHeaders:    []string{httpx.HeaderRequestID},
+		AllowCredentials: true,
+	})
+}
+
+// seedLocalUser creates the mock user with verified email and its password,
+// the password is set if it is missing, e.g. the profile was created by hand
+func (h *Handler) seedLocalUser(ctx context.Context) error {
+	_, err := h.ss.Profiles.Get(ctx, h.cfg.MockUserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return h.createLocalUser(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = h.ss.Credentials.Get(ctx, h.cfg.MockUserID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	hash, err := h.hasher.Hash(h.cfg.MockUserPassword)
+	if err != nil {
+		return err
+	}
+	if err := h.ss.Credentials.Save(ctx, &storage.Credential{ProfileID: h.cfg.MockUserID, PasswordHash: hash}); err != nil {
+		return err
+	}
+	h.logger.Infof("set password of mock user %s", h.cfg.MockUserID)
+	return nil
+}
+
+func (h *Handler) createLocalUser(ctx context.Context) error {
+	hash, err := h.hasher.Hash(h.cfg.MockUserPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	email := h.cfg.MockUserEmail
+	p := &storage.Profile{
+		ID:              h.cfg.MockUserID,
+		Email:           &email,
+		FirstName:       "Local",
+		LastName:        "User",
+		RulesAcceptedAt: &now,
+		EmailVerifiedAt: &now,
+	}
+	err = h.ss.CreateProfile(ctx, p, &storage.Credential{ProfileID: p.ID, PasswordHash: hash})
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		h.logger.Warnf("mock user is not seeded, %s belongs to another profile", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	h.logger.Infof("seeded mock user %s <%s>", p.ID, email)
+	return nil
+}
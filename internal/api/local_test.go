@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/storage"
+)
+
+func TestMockAuthMiddleware(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		status int
+		userID string
+	}{
+		{name: "mock user by default", status: http.StatusOK, userID: testMockUserID},
+		{name: "user from header", header: "6f1c3e4a-2b8d-4c1e-9a7f-0d5b2e8c4a11", status: http.StatusOK, userID: "6f1c3e4a-2b8d-4c1e-9a7f-0d5b2e8c4a11"},
+		{name: "not a profile id", header: "admin", status: http.StatusUnauthorized},
+	}
+
+	s := newTestServer(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil)
+			if tc.header != "" {
+				req.Header.Set(s.cfg.MockUserHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			c := s.NewContext(req, rec)
+
+			var userID string
+			err := s.mockAuthMiddleware(func(c echo.Context) error {
+				userID, _ = currentUserID(c)
+				return c.NoContent(http.StatusOK)
+			})(c)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.userID, userID)
+		})
+	}
+}
+
+func TestSeedLocalUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	h := &s.handler
+
+	require.NoError(t, h.seedLocalUser(ctx))
+	p, err := h.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, s.cfg.MockUserEmail, *p.Email)
+	assert.NotNil(t, p.EmailVerifiedAt)
+
+	cred, err := h.ss.Credentials.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	ok, err := h.hasher.Verify(s.cfg.MockUserPassword, cred.PasswordHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, h.seedLocalUser(ctx))
+	again, err := h.ss.Credentials.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, cred.PasswordHash, again.PasswordHash, "seeded user is kept as is")
+}
+
+func TestSeedLocalUserWithoutPassword(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	h := &s.handler
+
+	// a previous start created the profile but failed to save its password
+	email := s.cfg.MockUserEmail
+	require.NoError(t, h.ss.Profiles.Save(ctx, &storage.Profile{ID: testMockUserID, Email: &email}))
+
+	require.NoError(t, h.seedLocalUser(ctx))
+	cred, err := h.ss.Credentials.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	ok, err := h.hasher.Verify(s.cfg.MockUserPassword, cred.PasswordHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -8,12 +8,15 @@ import (
 	"github.com/labstack/echo/v4"
 	common "github.com/levongh/profile/common/config"
 	"github.com/levongh/profile/common/httpx"
-	// _ "github.com/levongh/profile/cmd/docs" // nolint:golint
+	"github.com/levongh/profile/internal/config"
 )
 
 func skipLoggingFunc(c echo.Context) bool {
 	uri := c.Request().RequestURI
-	return strings.Contains(uri, "health-check")
+	return strings.Contains(uri, "health-check") ||
+		strings.HasPrefix(uri, "/livez") ||
+		strings.HasPrefix(uri, "/readyz") ||
+		strings.HasPrefix(uri, "/metrics")
 }
 
 func (s *Server) makeIPCMiddleware(username, password string) func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -34,10 +37,42 @@ func (s *Server) makeIPCMiddleware(username, password string) func(next echo.Han
 
 func (s *Server) apiGatewayAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	if s.cfg.Mode == common.ModeLocal {
-		return func(c echo.Context) error {
-			// c.Set(string(httpx.ContextKeyUserID), s.cfg.MockUserID)
-			return next(c)
+		return s.mockAuthMiddleware(next)
+	}
+	return httpx.APIGateWayAuthMiddleware(next, s.identitySources()...)
+}
+
+// identitySources lists the ways callers are authenticated according to the trust mode
+func (s *Server) identitySources() []httpx.IdentitySource {
+	bearer := httpx.BearerIdentity{Verifier: s.handler.tokens}
+	switch s.cfg.AuthTrustMode {
+	case config.AuthTrustJWT:
+		return []httpx.IdentitySource{bearer}
+	case config.AuthTrustBoth:
+		return []httpx.IdentitySource{bearer, httpx.HeaderIdentity{}}
+	default:
+		return []httpx.IdentitySource{httpx.HeaderIdentity{}}
+	}
+}
+
+// sessionTrackingMiddleware records the session the authenticated user calls from, access tokens
+// of revoked sessions and devices signed out by revoking them are rejected until they sign in again.
+// Error messages are rendered in the locale of the access token
+func (s *Server) sessionTrackingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if userID, err := currentUserID(c); err == nil {
+			claims := s.handler.tokenClaims(c)
+			if claims != nil && claims.Locale != "" {
+				c.Set(httpx.ContextKeyUserLocale.String(), claims.Locale)
+			}
+			id, err := s.handler.touchSession(c, userID, claims)
+			if err != nil {
+				return unauthorized(c, err)
+			}
+			if id != "" {
+				c.Set(contextKeySessionID, id)
+			}
 		}
+		return next(c)
 	}
-	return httpx.APIGateWayAuthMiddleware(next)
 }
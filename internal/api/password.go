@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	fieldOldPassword     = "old_password"
+	fieldNewPassword     = "new_password"
+	fieldConfirmPassword = "confirm_password"
+)
+
+type changePasswordRequest struct {
+	OldPassword     string `json:"old_password" normalize:"-"`
+	NewPassword     string `json:"new_password" normalize:"-"`
+	ConfirmPassword string `json:"confirm_password" normalize:"-"`
+}
+
+func (r *changePasswordRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+
+	if r.OldPassword == "" {
+		out.AddFieldError(fieldOldPassword, validation.EmptyPassword())
+	}
+	validateNewPassword(out, fieldNewPassword, r.NewPassword, r.ConfirmPassword)
+
+	return out
+}
+
+// validateNewPassword checks the password policy and its confirmation
+func validateNewPassword(out *validation.Result, field, password, confirmation string) {
+	switch {
+	case password == "":
+		out.AddFieldError(field, validation.EmptyPassword())
+	case !validation.IsPasswordValid(password):
+		out.AddFieldError(field, validation.InvalidPassword())
+	}
+
+	if confirmation != password {
+		out.AddFieldError(fieldConfirmPassword, validation.InvalidConfirmPassword())
+	}
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Verifies the old password and sets the new one, other sessions are revoked
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body changePasswordRequest true "passwords"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /profile/password [post]
+func (h *Handler) ChangePassword(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req changePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	ok, err := h.countProfileAttempt(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !ok {
+		return tooManyAttempts(c)
+	}
+
+	cred, err := h.ss.Credentials.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	ok, err = h.verifyPassword(ctx, cred, req.OldPassword)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		res := validation.NewResult().AddFieldError(fieldOldPassword, validation.WrongPassword())
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	if err := h.setPassword(ctx, cred, req.NewPassword); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	// the password is already changed, the client retries with the new password as the old one
+	if err := h.revokeSessions(ctx, userID, currentSessionID(c)); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditPasswordChanged)
+	h.notifyPasswordChanged(ctx, userID)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// verifyPassword checks the password and transparently upgrades its hash
+// if hashing parameters were changed since it was stored
+func (h *Handler) verifyPassword(ctx context.Context, cred *storage.Credential, password string) (bool, error) {
+	ok, err := h.hasher.Verify(password, cred.PasswordHash)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if h.hasher.NeedsRehash(cred.PasswordHash) {
+		hash, err := h.hasher.Hash(password)
+		if err == nil {
+			cred.PasswordHash = hash
+			err = h.ss.Credentials.Save(ctx, cred)
+		}
+		// user provided correct password, so failed upgrade is not a reason to deny
+		if err != nil {
+			h.logger.WithContext(ctx).Errorf("failed to rehash password of %s: %s", cred.ProfileID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// setPassword stores the new password, sessions started before now are not valid anymore
+func (h *Handler) setPassword(ctx context.Context, cred *storage.Credential, password string) error {
+	hash, err := h.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	cred.PasswordHash = hash
+	cred.PasswordChangedAt = &now
+
+	return h.ss.Credentials.Save(ctx, cred)
+}
+
+// notifyPasswordChanged lets the user know about the change in case it wasn't them,
+// failures are only logged as the password is already changed
+func (h *Handler) notifyPasswordChanged(ctx context.Context, profileID string) {
+	p, err := h.ss.Profiles.Get(ctx, profileID)
+	if err != nil || p.Email == nil {
+		return
+	}
+
+	msg, err := mail.PasswordChanged.Render(*p.Email, h.securityMailData(ctx, profileID, mail.Data{}))
+	if err == nil {
+		err = h.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		h.logger.WithContext(ctx).Errorf("failed to notify %s about password change: %s", profileID, err)
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	resetPasswordPath = "/reset-password"
+
+	// forgotPasswordTimeout limits background delivery of the reset link
+	forgotPasswordTimeout = 30 * time.Second
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" normalize:"trim,lower"`
+}
+
+func (r *forgotPasswordRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if !validation.IsEmailValid(r.Email) {
+		out.AddFieldError(validation.EmailField, validation.InvalidEmail())
+	}
+	return out
+}
+
+type resetPasswordRequest struct {
+	Token           string `json:"token"`
+	Password        string `json:"password" normalize:"-"`
+	ConfirmPassword string `json:"confirm_password" normalize:"-"`
+}
+
+func (r *resetPasswordRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Token == "" {
+		out.AddFieldError(fieldToken, validation.InvalidResetToken())
+	}
+	validateNewPassword(out, fieldPassword, r.Password, r.ConfirmPassword)
+	return out
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Sends password reset link if the email is registered, response doesn't depend on it
+// @Tags password
+// @Accept json
+// @Produce json
+// @Param request body forgotPasswordRequest true "email"
+// @Success 202
+// @Failure 400 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /password/forgot [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var req forgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	// unknown emails are counted too, so the limit doesn't reveal whether the account exists
+	ok, err := h.countAttempt(c.Request().Context(),
+		attemptLimit{key: attemptKey(attemptKeyReset, req.Email), max: h.cfg.PasswordResetMaxAttempts},
+		attemptLimit{key: attemptKey(attemptKeyIP, c.RealIP()), max: h.cfg.LoginIPMaxAttempts},
+	)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !ok {
+		return tooManyAttempts(c)
+	}
+
+	// lookup and delivery are done in background, so neither the response
+	// nor its timing reveal whether the account exists
+	h.tasks.Go(c.Request().Context(), forgotPasswordTimeout, func(ctx context.Context) {
+		if err := h.sendResetLink(ctx, req.Email); err != nil {
+			h.logger.WithContext(ctx).Errorf("failed to send password reset link: %s", err)
+		}
+	})
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *Handler) sendResetLink(ctx context.Context, email string) error {
+	p, err := h.ss.Profiles.FindByEmail(ctx, email)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := secret.NewToken()
+	if err != nil {
+		return err
+	}
+
+	err = h.ss.VerificationTokens.Create(ctx, &storage.VerificationToken{
+		ProfileID:   p.ID,
+		Purpose:     storage.PurposePasswordReset,
+		Destination: *p.Email,
+		TokenHash:   hash,
+		ExpiresAt:   time.Now().Add(h.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := mail.ResetPassword.Render(*p.Email, h.securityMailData(ctx, p.ID, mail.Data{
+		Link:      h.clientLink(resetPasswordPath, url.Values{fieldToken: {token}}),
+		ExpiresIn: h.cfg.PasswordResetTTL,
+	}))
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, msg)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password using the token sent by ForgotPassword, all sessions are revoked
+// @Tags password
+// @Accept json
+// @Produce json
+// @Param request body resetPasswordRequest true "token and password"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Router /password/reset [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req resetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+	invalidToken := validation.NewResult().AddFieldError(fieldToken, validation.InvalidResetToken())
+
+	t, err := h.ss.VerificationTokens.Consume(ctx, storage.PurposePasswordReset, secret.HashToken(req.Token))
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, invalidToken)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	p, err := h.ss.Profiles.Get(ctx, t.ProfileID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, invalidToken)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	// email was changed after the link had been sent
+	if p.Email == nil || !strings.EqualFold(*p.Email, t.Destination) {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, invalidToken)
+	}
+
+	cred, err := h.ss.Credentials.Get(ctx, p.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		cred = &storage.Credential{ProfileID: p.ID}
+	} else if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	if err := h.setPassword(ctx, cred, req.Password); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	// the token is already used, so the client retries with a new link, which revokes sessions again
+	if err := h.revokeSessions(ctx, p.ID, ""); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, p.ID, storage.AuditPasswordReset)
+	h.notifyPasswordChanged(ctx, p.ID)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// audit records the action made by the current request, failures are only logged
+func (h *Handler) audit(c echo.Context, profileID, action string) {
+	err := h.ss.Audit.Add(c.Request().Context(), &storage.AuditEntry{
+		ProfileID: profileID,
+		Action:    action,
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	})
+	if err != nil {
+		h.logger.WithContext(c.Request().Context()).Errorf("failed to record %s audit entry of %s: %s", action, profileID, err)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+)
+
+var linkTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func forgotPassword(t *testing.T, s *Server, email string) int {
+	c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"email":%q}`, email))
+	require.NoError(t, s.handler.ForgotPassword(c))
+	require.NoError(t, s.handler.tasks.Wait(context.Background()))
+	return rec.Code
+}
+
+func resetPassword(t *testing.T, s *Server, resetToken, password string) int {
+	c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"token":%q,"password":%q,"confirm_password":%q}`, resetToken, password, password))
+	require.NoError(t, s.handler.ResetPassword(c))
+	return rec.Code
+}
+
+func TestForgotPassword(t *testing.T) {
+	s := newTestServer(t)
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	mailer := s.handler.mailer.(*fakeMailer)
+
+	assert.Equal(t, http.StatusAccepted, forgotPassword(t, s, "unknown@profile.local"))
+	assert.Empty(t, mailer.sent, "unknown email")
+
+	assert.Equal(t, http.StatusAccepted, forgotPassword(t, s, strings.ToUpper(s.cfg.MockUserEmail)))
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, s.cfg.MockUserEmail, mailer.sent[0].To)
+	match := linkTokenPattern.FindStringSubmatch(mailer.sent[0].Body)
+	require.Len(t, match, 2)
+
+	const newPassword = "New-pa55word"
+	assert.Equal(t, http.StatusNoContent, resetPassword(t, s, match[1], newPassword))
+	assert.Equal(t, http.StatusBadRequest, resetPassword(t, s, match[1], newPassword), "the link is single-use")
+	signIn(t, s, newPassword)
+}
+
+func TestForgotPasswordLimited(t *testing.T) {
+	for _, email := range []string{"local@profile.local", "unknown@profile.local"} {
+		t.Run(email, func(t *testing.T) {
+			s := newTestServer(t)
+			require.NoError(t, s.handler.seedLocalUser(context.Background()))
+			mailer := s.handler.mailer.(*fakeMailer)
+
+			for i := 0; i < s.cfg.PasswordResetMaxAttempts; i++ {
+				require.Equal(t, http.StatusAccepted, forgotPassword(t, s, email))
+			}
+			sent := len(mailer.sent)
+			assert.Equal(t, http.StatusTooManyRequests, forgotPassword(t, s, email))
+			assert.Len(t, mailer.sent, sent)
+			assert.Equal(t, http.StatusAccepted, forgotPassword(t, s, "other@profile.local"), "other emails aren't limited")
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	testCases := []struct {
+		name        string
+		destination string
+		wantStatus  int
+	}{
+		{name: "sent to the profile email", destination: "local@profile.local", wantStatus: http.StatusNoContent},
+		{name: "email differs in case", destination: "Local@Profile.local", wantStatus: http.StatusNoContent},
+		{name: "email changed after the link was sent", destination: "old@profile.local", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestServer(t)
+			require.NoError(t, s.handler.seedLocalUser(ctx))
+			pair := signIn(t, s, s.cfg.MockUserPassword)
+
+			resetToken, hash, err := secret.NewToken()
+			require.NoError(t, err)
+			require.NoError(t, s.ss.VerificationTokens.Create(ctx, &storage.VerificationToken{
+				ProfileID:   testMockUserID,
+				Purpose:     storage.PurposePasswordReset,
+				Destination: tc.destination,
+				TokenHash:   hash,
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}))
+
+			const newPassword = "New-pa55word"
+			require.Equal(t, tc.wantStatus, resetPassword(t, s, resetToken, newPassword))
+			if tc.wantStatus != http.StatusNoContent {
+				signIn(t, s, s.cfg.MockUserPassword)
+				return
+			}
+
+			_, status := refresh(t, s, pair.RefreshToken)
+			assert.Equal(t, http.StatusUnauthorized, status, "sessions are revoked")
+			signIn(t, s, newPassword)
+		})
+	}
+}
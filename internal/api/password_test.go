@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changePasswordBody(oldPassword, newPassword string) string {
+	return fmt.Sprintf(`{"old_password":%q,"new_password":%q,"confirm_password":%q}`, oldPassword, newPassword, newPassword)
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	s := newJWTTestServer(t)
+	current := signInFrom(t, s, testDevice, s.cfg.MockUserPassword)
+	other := signInFrom(t, s, "phone", s.cfg.MockUserPassword)
+
+	const newPassword = "New-pa55word"
+	status := serveAuthenticated(t, s, current.AccessToken, "", changePasswordBody(s.cfg.MockUserPassword, newPassword), s.handler.ChangePassword)
+	require.Equal(t, http.StatusNoContent, status)
+
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(t, s, other.AccessToken, ""), "access token of the other session")
+	_, status = refresh(t, s, other.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	assert.Equal(t, http.StatusOK, serveWithToken(t, s, current.AccessToken, ""), "the session the password is changed from")
+	_, status = refresh(t, s, current.RefreshToken)
+	assert.Equal(t, http.StatusOK, status)
+	signIn(t, s, newPassword)
+}
+
+func TestChangePasswordLimited(t *testing.T) {
+	s := newJWTTestServer(t)
+	pair := signIn(t, s, s.cfg.MockUserPassword)
+	changePassword := func(oldPassword string) int {
+		return serveAuthenticated(t, s, pair.AccessToken, "", changePasswordBody(oldPassword, "New-pa55word"), s.handler.ChangePassword)
+	}
+
+	for i := 0; i < s.cfg.LoginMaxAttempts; i++ {
+		require.Equal(t, http.StatusBadRequest, changePassword("Wrong-pa55word"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, changePassword(s.cfg.MockUserPassword), "guesses of the old password are limited")
+}
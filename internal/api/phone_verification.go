@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/otp"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const fieldCode = "code"
+
+type startPhoneVerificationRequest struct {
+	Phone              string `json:"phone"`
+	CountryCallingCode string `json:"country_calling_code"`
+}
+
+func (r *startPhoneVerificationRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Phone == "" {
+		out.AddFieldError(validation.PhoneField, validation.InvalidPhone())
+		return out
+	}
+	validateContacts(out, nil, &r.Phone, &r.CountryCallingCode, "")
+	return out
+}
+
+// e164 formats the phone for SMS delivery
+func (r *startPhoneVerificationRequest) e164() string {
+	return "+" + r.CountryCallingCode + r.Phone
+}
+
+type confirmPhoneVerificationRequest struct {
+	Phone              string `json:"phone"`
+	CountryCallingCode string `json:"country_calling_code"`
+	Code               string `json:"code"`
+}
+
+func (r *confirmPhoneVerificationRequest) phone() *startPhoneVerificationRequest {
+	return &startPhoneVerificationRequest{Phone: r.Phone, CountryCallingCode: r.CountryCallingCode}
+}
+
+func (r *confirmPhoneVerificationRequest) Validate() *validation.Result {
+	out := r.phone().Validate()
+	if r.Code == "" {
+		out.AddFieldError(fieldCode, validation.InvalidOtpCode())
+	}
+	return out
+}
+
+// StartPhoneVerification godoc
+// @Summary Start phone verification
+// @Description Sends OTP code to the phone, the phone is set to the profile once the code is confirmed
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body startPhoneVerificationRequest true "phone"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /profile/phone/verification [post]
+func (h *Handler) StartPhoneVerification(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req startPhoneVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	p, err := h.ss.Profiles.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	res := validation.NewResult()
+	if p.Country != "" && !validation.IsCountryCallingCodeValid(p.Country, req.CountryCallingCode) {
+		res.AddFieldError(fieldCountryCallingCode, validation.WrongCountryCallingCode())
+	}
+	other, err := h.ss.Profiles.FindByPhone(ctx, req.CountryCallingCode, req.Phone)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if other != nil && other.ID != p.ID {
+		res.AddFieldError(validation.PhoneField, validation.UserAlreadyExists())
+	}
+	if !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	err = h.otp.Start(ctx, p.ID, storage.PurposePhoneVerification, req.e164())
+	if errors.Is(err, otp.ErrTooEarly) {
+		return httpx.JSONErr(c, err, http.StatusTooManyRequests, validation.CodeError(validation.OtpSentRecently().Code, err))
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ConfirmPhoneVerification godoc
+// @Summary Confirm phone verification
+// @Description Checks OTP code sent by StartPhoneVerification and sets verified phone to the profile
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body confirmPhoneVerificationRequest true "phone and code"
+// @Success 200 {object} profileResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Router /profile/phone/verification/confirm [post]
+func (h *Handler) ConfirmPhoneVerification(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req confirmPhoneVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	p, err := h.ss.Profiles.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	err = h.otp.Verify(ctx, p.ID, storage.PurposePhoneVerification, req.phone().e164(), req.Code)
+	if errors.Is(err, otp.ErrInvalidCode) {
+		res := validation.NewResult().AddFieldError(fieldCode, validation.InvalidOtpCode())
+		return httpx.JSONErr(c, err, http.StatusBadRequest, res)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	now := time.Now().UTC()
+	p, err = h.ss.Profiles.Update(ctx, p.ID, storage.ProfileUpdate{
+		Phone:              &req.Phone,
+		CountryCallingCode: &req.CountryCallingCode,
+		PhoneVerifiedAt:    &now,
+	})
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return contactTakenErr(c, err)
+	}
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newProfileResponse(p))
+}
@@ -0,0 +1,419 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	minimalAge = 18
+
+	fieldFirstName          = "first_name"
+	fieldLastName           = "last_name"
+	fieldCountry            = "country"
+	fieldCountryCallingCode = "country_calling_code"
+	fieldBirthdate          = "birthdate"
+	fieldLocale             = "locale"
+)
+
+type profileResponse struct {
+	ID                 string    `json:"id"`
+	Email              *string   `json:"email"`
+	Phone              *string   `json:"phone"`
+	CountryCallingCode *string   `json:"country_calling_code"`
+	FirstName          string    `json:"first_name"`
+	LastName           string    `json:"last_name"`
+	Birthdate          *string   `json:"birthdate"`
+	Country            string    `json:"country"`
+	Locale             string    `json:"locale"`
+	EmailVerified      bool      `json:"email_verified"`
+	PhoneVerified      bool      `json:"phone_verified"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func newProfileResponse(p *storage.Profile) profileResponse {
+	out := profileResponse{
+		ID:                 p.ID,
+		Email:              p.Email,
+		Phone:              p.Phone,
+		CountryCallingCode: p.CountryCallingCode,
+		FirstName:          p.FirstName,
+		LastName:           p.LastName,
+		Country:            p.Country,
+		Locale:             p.Locale,
+		EmailVerified:      p.EmailVerifiedAt != nil,
+		PhoneVerified:      p.PhoneVerifiedAt != nil,
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+	}
+	if p.Birthdate != nil {
+		birthdate := p.Birthdate.Format(dateLayout)
+		out.Birthdate = &birthdate
+	}
+	return out
+}
+
+// profileContacts are changed only by RequestEmailVerification and StartPhoneVerification,
+// profile updates accept them as they are stored, so clients can send back the profile they got
+type profileContacts struct {
+	Email              *string
+	Phone              *string
+	CountryCallingCode *string
+}
+
+// checkUnchanged reports contacts which differ from the stored ones
+func (r profileContacts) checkUnchanged(out *validation.Result, p *storage.Profile) {
+	if r.Email != nil && (p.Email == nil || !strings.EqualFold(*p.Email, *r.Email)) {
+		out.AddFieldError(validation.EmailField, validation.VerificationRequired())
+	}
+	if r.Phone != nil && !equalPtr(p.Phone, r.Phone) {
+		out.AddFieldError(validation.PhoneField, validation.VerificationRequired())
+	}
+	if r.CountryCallingCode != nil && !equalPtr(p.CountryCallingCode, r.CountryCallingCode) {
+		out.AddFieldError(fieldCountryCallingCode, validation.VerificationRequired())
+	}
+}
+
+// putProfileRequest replaces all editable fields of a profile
+type putProfileRequest struct {
+	FirstName          string  `json:"first_name" normalize:"collapse_spaces,nfc"`
+	LastName           string  `json:"last_name" normalize:"collapse_spaces,nfc"`
+	Email              *string `json:"email" normalize:"trim,lower"`
+	Phone              *string `json:"phone"`
+	CountryCallingCode *string `json:"country_calling_code"`
+	Country            string  `json:"country"`
+	Birthdate          *string `json:"birthdate"`
+	Locale             string  `json:"locale" normalize:"trim,lower"`
+}
+
+func (r *putProfileRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+
+	validateName(out, fieldFirstName, r.FirstName)
+	validateName(out, fieldLastName, r.LastName)
+	validateCountry(out, r.Country)
+	validateBirthdate(out, r.Birthdate)
+	validateLocale(out, r.Locale)
+
+	return out
+}
+
+func (r *putProfileRequest) contacts() profileContacts {
+	return profileContacts{Email: r.Email, Phone: r.Phone, CountryCallingCode: r.CountryCallingCode}
+}
+
+func (r *putProfileRequest) update() storage.ProfileUpdate {
+	return storage.ProfileUpdate{
+		FirstName:    &r.FirstName,
+		LastName:     &r.LastName,
+		Country:      &r.Country,
+		Locale:       &r.Locale,
+		Birthdate:    parseBirthdate(r.Birthdate),
+		SetBirthdate: true,
+	}
+}
+
+// patchProfileRequest updates only provided fields of a profile
+type patchProfileRequest struct {
+	FirstName          *string `json:"first_name" normalize:"collapse_spaces,nfc"`
+	LastName           *string `json:"last_name" normalize:"collapse_spaces,nfc"`
+	Email              *string `json:"email" normalize:"trim,lower"`
+	Phone              *string `json:"phone"`
+	CountryCallingCode *string `json:"country_calling_code"`
+	Country            *string `json:"country"`
+	Birthdate          *string `json:"birthdate"`
+	Locale             *string `json:"locale" normalize:"trim,lower"`
+}
+
+// Validate checks only the fields present in the request, contacts are checked
+// by PatchProfile against the stored profile
+func (r *patchProfileRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+
+	if r.FirstName != nil {
+		validateName(out, fieldFirstName, *r.FirstName)
+	}
+	if r.LastName != nil {
+		validateName(out, fieldLastName, *r.LastName)
+	}
+	if r.Country != nil {
+		validateCountry(out, *r.Country)
+	}
+	if r.Birthdate != nil {
+		validateBirthdate(out, r.Birthdate)
+	}
+	if r.Locale != nil {
+		validateLocale(out, *r.Locale)
+	}
+
+	return out
+}
+
+func (r *patchProfileRequest) contacts() profileContacts {
+	return profileContacts{Email: r.Email, Phone: r.Phone, CountryCallingCode: r.CountryCallingCode}
+}
+
+func (r *patchProfileRequest) update() storage.ProfileUpdate {
+	return storage.ProfileUpdate{
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		Country:      r.Country,
+		Locale:       r.Locale,
+		Birthdate:    parseBirthdate(r.Birthdate),
+		SetBirthdate: r.Birthdate != nil,
+	}
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func validateName(out *validation.Result, field, name string) {
+	if !validation.IsNameValid(name) {
+		out.AddFieldError(field, validation.NameIsTooShort())
+		return
+	}
+	if !validation.IsAlpha(name) {
+		out.AddFieldError(field, validation.NotOnlyLetters())
+	}
+}
+
+func validateCountry(out *validation.Result, country string) {
+	if country != "" && countries.ByName(country) == countries.Unknown {
+		out.AddFieldError(fieldCountry, validation.UnknownCountry())
+	}
+}
+
+// validateContacts checks email, phone and the calling code of the phone,
+// calling code is matched against the country when it is known
+func validateContacts(out *validation.Result, email, phone, callingCode *string, country string) {
+	if email != nil && !validation.IsEmailValid(*email) {
+		out.AddFieldError(validation.EmailField, validation.InvalidEmail())
+	}
+
+	if phone == nil {
+		return
+	}
+	if !validation.HasOnlyDigits(phone) {
+		out.AddFieldError(validation.PhoneField, validation.WrongPhoneFormat())
+	}
+	if !validation.HasOnlyDigits(callingCode) {
+		out.AddFieldError(fieldCountryCallingCode, validation.InvalidCountryCallingCodeFormat())
+		return
+	}
+	if country != "" && !validation.IsCountryCallingCodeValid(country, *callingCode) {
+		out.AddFieldError(fieldCountryCallingCode, validation.WrongCountryCallingCode())
+	}
+}
+
+func validateBirthdate(out *validation.Result, in *string) {
+	if in == nil || *in == "" {
+		return
+	}
+
+	birthdate, err := time.Parse(dateLayout, *in)
+	if err != nil {
+		out.AddFieldError(fieldBirthdate, validation.InvalidBirthDate())
+		return
+	}
+	if !validation.IsOlderThan(birthdate, minimalAge) {
+		out.AddFieldError(fieldBirthdate, validation.TooYoungAge())
+	}
+}
+
+// validateLocale accepts only languages error messages are translated to, empty locale is allowed
+func validateLocale(out *validation.Result, locale string) {
+	if locale != "" && !validation.IsLanguageSupported(locale) {
+		out.AddFieldErrorWithData(fieldLocale, validation.UnsupportedLocale(),
+			map[string]interface{}{"allowed": validation.Languages()}, 0)
+	}
+}
+
+// parseBirthdate expects input already checked by validateBirthdate,
+// empty value clears the birthdate
+func parseBirthdate(in *string) *time.Time {
+	if in == nil || *in == "" {
+		return nil
+	}
+	birthdate, err := time.Parse(dateLayout, *in)
+	if err != nil {
+		return nil
+	}
+	return &birthdate
+}
+
+// currentUserID returns id of the user resolved by apiGatewayAuthMiddleware
+func currentUserID(c echo.Context) (string, error) {
+	userID, ok := c.Get(httpx.ContextKeyUserID.String()).(string)
+	if !ok || userID == "" {
+		return "", httpx.ErrUserIDIsMissing
+	}
+	return userID, nil
+}
+
+func unauthorized(c echo.Context, err error) error {
+	return httpx.JSONErr(c, err, http.StatusUnauthorized, validation.CodeError(validation.Unauthorized().Code, err))
+}
+
+// GetProfile godoc
+// @Summary Get profile
+// @Description Returns profile of the authenticated user
+// @Tags profile
+// @Produce json
+// @Success 200 {object} profileResponse
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile [get]
+func (h *Handler) GetProfile(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	p, err := h.ss.Profiles.Get(c.Request().Context(), userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newProfileResponse(p))
+}
+
+// PutProfile godoc
+// @Summary Replace profile
+// @Description Replaces all editable fields of the authenticated user profile. Email and phone are changed
+// @Description by their verification, so they are accepted only as they are stored
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body putProfileRequest true "profile"
+// @Success 200 {object} profileResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile [put]
+func (h *Handler) PutProfile(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req putProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	return h.updateProfile(c, userID, req.contacts(), req.update())
+}
+
+// PatchProfile godoc
+// @Summary Update profile
+// @Description Updates only provided fields of the authenticated user profile. Email and phone are changed
+// @Description by their verification, so they are accepted only as they are stored
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body patchProfileRequest true "profile fields"
+// @Success 200 {object} profileResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile [patch]
+func (h *Handler) PatchProfile(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req patchProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	return h.updateProfile(c, userID, req.contacts(), req.update())
+}
+
+// updateProfile changes only the fields of the update, profiles are created on registration,
+// so 404 is returned for a missing one
+func (h *Handler) updateProfile(c echo.Context, userID string, contacts profileContacts, u storage.ProfileUpdate) error {
+	ctx := c.Request().Context()
+
+	p, err := h.ss.Profiles.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	res := validation.NewResult()
+	contacts.checkUnchanged(res, p)
+	country := p.Country
+	if u.Country != nil {
+		country = *u.Country
+	}
+	// the stored calling code has to match the new country
+	validateContacts(res, nil, p.Phone, p.CountryCallingCode, country)
+	if !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	p, err = h.ss.Profiles.Update(ctx, userID, u)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newProfileResponse(p))
+}
+
+// DeleteProfile godoc
+// @Summary Delete profile
+// @Description Deletes profile of the authenticated user
+// @Tags profile
+// @Success 204
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile [delete]
+func (h *Handler) DeleteProfile(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	if err := h.ss.Profiles.Delete(c.Request().Context(), userID); err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// contactTakenErr responds to a contact change rejected as the contact belongs to another profile
+func contactTakenErr(c echo.Context, err error) error {
+	res := validation.NewResult().AddCode(validation.UserAlreadyExists().Code).
+		AddDetails(validation.UserAlreadyExists().Message)
+	return httpx.JSONErr(c, err, http.StatusConflict, res)
+}
+
+// profileLoadErr responds to a failed lookup of the profile by id
+func profileLoadErr(c echo.Context, err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusNotFound, validation.ProfileNotFound())
+	}
+	return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+}
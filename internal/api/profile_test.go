@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+)
+
+// saveTestProfile stores the profile of the mock user with verified contacts
+func saveTestProfile(t *testing.T, s *Server) *storage.Profile {
+	email, phone, callingCode := "anna@profile.local", "5550100", "1"
+	birthdate := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	verifiedAt := time.Now().UTC()
+	p := &storage.Profile{
+		ID:                 testMockUserID,
+		Email:              &email,
+		Phone:              &phone,
+		CountryCallingCode: &callingCode,
+		FirstName:          "Anna",
+		LastName:           "Smith",
+		Birthdate:          &birthdate,
+		Country:            "United States",
+		EmailVerifiedAt:    &verifiedAt,
+		PhoneVerifiedAt:    &verifiedAt,
+	}
+	require.NoError(t, s.ss.Profiles.Save(context.Background(), p))
+	return p
+}
+
+func updateProfile(t *testing.T, s *Server, handler echo.HandlerFunc, method, body string) (int, *validation.Result) {
+	c, rec := newUserContext(s, method, body)
+	require.NoError(t, handler(c))
+	if rec.Code == http.StatusOK {
+		return rec.Code, nil
+	}
+
+	var res validation.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return rec.Code, &res
+}
+
+func TestPutProfile(t *testing.T) {
+	t.Run("missing profile isn't created", func(t *testing.T) {
+		s := newTestServer(t)
+		status, _ := updateProfile(t, s, s.handler.PutProfile, http.MethodPut, `{"first_name":"Anna","last_name":"Smith"}`)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		_, err := s.ss.Profiles.Get(context.Background(), testMockUserID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{
+			name:       "stored contacts sent back",
+			body:       `{"first_name":"Maria","last_name":"Jones","email":"Anna@Profile.local","phone":"5550100","country_calling_code":"1","country":"United States"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "without contacts",
+			body:       `{"first_name":"Maria","last_name":"Jones","country":"United States"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "changed email",
+			body:       `{"first_name":"Maria","last_name":"Jones","email":"maria@profile.local"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  validation.EmailField,
+		},
+		{
+			name:       "changed phone",
+			body:       `{"first_name":"Maria","last_name":"Jones","phone":"5550199","country_calling_code":"1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  validation.PhoneField,
+		},
+		{
+			name:       "country of another calling code",
+			body:       `{"first_name":"Maria","last_name":"Jones","country":"Armenia"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  fieldCountryCallingCode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t)
+			stored := saveTestProfile(t, s)
+
+			status, res := updateProfile(t, s, s.handler.PutProfile, http.MethodPut, tc.body)
+			require.Equal(t, tc.wantStatus, status)
+
+			p, err := s.ss.Profiles.Get(context.Background(), testMockUserID)
+			require.NoError(t, err)
+			assert.Equal(t, stored.Email, p.Email)
+			assert.Equal(t, stored.Phone, p.Phone)
+			assert.NotNil(t, p.EmailVerifiedAt)
+			assert.NotNil(t, p.PhoneVerifiedAt)
+
+			if tc.wantStatus != http.StatusOK {
+				require.Len(t, res.Errors, 1)
+				assert.Equal(t, tc.wantField, res.Errors[0].Name)
+				assert.Equal(t, "Anna", p.FirstName)
+				return
+			}
+			assert.Equal(t, "Maria", p.FirstName)
+			assert.Equal(t, "Jones", p.LastName)
+			assert.Nil(t, p.Birthdate, "every editable field is replaced")
+		})
+	}
+}
+
+func TestPatchProfile(t *testing.T) {
+	s := newTestServer(t)
+	stored := saveTestProfile(t, s)
+
+	status, _ := updateProfile(t, s, s.handler.PatchProfile, http.MethodPatch, `{"first_name":"Maria","email":"anna@profile.local"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	p, err := s.ss.Profiles.Get(context.Background(), testMockUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "Maria", p.FirstName)
+	assert.Equal(t, "Smith", p.LastName, "fields not sent are kept")
+	assert.Equal(t, stored.Birthdate, p.Birthdate)
+	assert.NotNil(t, p.EmailVerifiedAt)
+
+	status, res := updateProfile(t, s, s.handler.PatchProfile, http.MethodPatch, `{"email":"maria@profile.local"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, validation.VerificationRequired().Code, res.Errors[0].Codes[0].Code)
+
+	status, _ = updateProfile(t, s, s.handler.PatchProfile, http.MethodPatch, `{"birthdate":""}`)
+	require.Equal(t, http.StatusOK, status)
+	p, err = s.ss.Profiles.Get(context.Background(), testMockUserID)
+	require.NoError(t, err)
+	assert.Nil(t, p.Birthdate, "empty birthdate clears it")
+	assert.Equal(t, "Maria", p.FirstName)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	fieldPassword      = "password"
+	fieldRulesAccepted = "rules_accepted"
+)
+
+type registrationRequest struct {
+	Email              *string `json:"email" normalize:"trim,lower"`
+	Phone              *string `json:"phone"`
+	CountryCallingCode *string `json:"country_calling_code"`
+	Password           string  `json:"password" normalize:"-"`
+	Birthdate          string  `json:"birthdate"`
+	RulesAccepted      bool    `json:"rules_accepted"`
+}
+
+// Validate collects all errors at once, so FE can show them for every field
+func (r *registrationRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+
+	// empty contact is the same as not provided one
+	if r.Email != nil && *r.Email == "" {
+		r.Email = nil
+	}
+	if r.Phone != nil && *r.Phone == "" {
+		r.Phone = nil
+	}
+
+	switch {
+	case r.Email != nil && r.Phone != nil:
+		both := validation.BothEmailAndPhoneProvided()
+		out.AddCode(both.Code).AddDetails(both.Details)
+	case r.Email == nil && r.Phone == nil:
+		out.AddFieldError(validation.EmailField, validation.EitherPhoneOrEmail())
+		out.AddFieldError(validation.PhoneField, validation.EitherPhoneOrEmail())
+	}
+	validateContacts(out, r.Email, r.Phone, r.CountryCallingCode, "")
+
+	switch {
+	case r.Password == "":
+		out.AddFieldError(fieldPassword, validation.EmptyPassword())
+	case !validation.IsPasswordValid(r.Password):
+		out.AddFieldError(fieldPassword, validation.InvalidPassword())
+	}
+
+	if r.Birthdate == "" {
+		out.AddFieldError(fieldBirthdate, validation.EmptyBirthDate())
+	} else {
+		validateBirthdate(out, &r.Birthdate)
+	}
+
+	if !r.RulesAccepted {
+		out.AddFieldError(fieldRulesAccepted, validation.RulesNotAccepted())
+	}
+
+	return out
+}
+
+// Register godoc
+// @Summary Register
+// @Description Creates a profile with either email or phone, all validation errors are returned at once
+// @Tags registration
+// @Accept json
+// @Produce json
+// @Param request body registrationRequest true "registration"
+// @Success 201 {object} profileResponse
+// @Failure 400 {object} validation.Result
+// @Router /registration [post]
+func (h *Handler) Register(c echo.Context) error {
+	var req registrationRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+
+	ctx := c.Request().Context()
+
+	res := validation.Validate(&req)
+	if err := h.checkRegistered(ctx, res, &req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	hash, err := h.hasher.Hash(req.Password)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	now := time.Now().UTC()
+	p := &storage.Profile{
+		ID:                 uuid.NewString(),
+		Email:              req.Email,
+		Phone:              req.Phone,
+		CountryCallingCode: req.CountryCallingCode,
+		Birthdate:          parseBirthdate(&req.Birthdate),
+		RulesAcceptedAt:    &now,
+	}
+	if req.Phone == nil {
+		p.CountryCallingCode = nil
+	}
+
+	err = h.ss.CreateProfile(ctx, p, &storage.Credential{ProfileID: p.ID, PasswordHash: hash})
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		// registered concurrently after checkRegistered
+		res.AddFieldError(contactField(&req), validation.UserAlreadyExists())
+		return httpx.JSONErr(c, err, http.StatusBadRequest, res)
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.JSON(http.StatusCreated, newProfileResponse(p))
+}
+
+// checkRegistered adds UserAlreadyExists to the result if provided contact is taken,
+// malformed contacts are skipped as they already have errors
+func (h *Handler) checkRegistered(ctx context.Context, res *validation.Result, req *registrationRequest) error {
+	var err error
+	switch {
+	case req.Email != nil && validation.IsEmailValid(*req.Email):
+		_, err = h.ss.Profiles.FindByEmail(ctx, *req.Email)
+	case req.Phone != nil && validation.HasOnlyDigits(req.Phone) && validation.HasOnlyDigits(req.CountryCallingCode):
+		_, err = h.ss.Profiles.FindByPhone(ctx, *req.CountryCallingCode, *req.Phone)
+	default:
+		return nil
+	}
+
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	res.AddFieldError(contactField(req), validation.UserAlreadyExists())
+	return nil
+}
+
+func contactField(req *registrationRequest) string {
+	if req.Email != nil {
+		return validation.EmailField
+	}
+	return validation.PhoneField
+}
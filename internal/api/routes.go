@@ -1,23 +1,71 @@
 package api
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
+// internalSwaggerInstance documents /internal/v1, it is generated from operations tagged internal
+const internalSwaggerInstance = "internal"
+
 func (s *Server) initRoutes() {
 	s.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	s.GET("/health-check", func(c echo.Context) error {
+		if s.IsShuttingDown() {
+			return c.String(http.StatusServiceUnavailable, "shutting down")
+		}
 		return c.String(http.StatusOK, "ok")
 	})
 
+	s.GET("/livez", s.livez)
+	s.GET("/readyz", s.readyz)
+
+	s.GET("/.well-known/jwks.json", s.handler.JWKS)
+
 	v1 := s.Group("/api/v1")
 	{
-		//TODO revisit
-		fmt.Println(v1)
+		v1.POST("/registration", s.handler.Register)
+		v1.POST("/email/verification/confirm", s.handler.ConfirmEmailVerification)
+		v1.POST("/password/forgot", s.handler.ForgotPassword)
+		v1.POST("/password/reset", s.handler.ResetPassword)
+		v1.POST("/auth/token", s.handler.Token)
+		v1.POST("/auth/revoke", s.handler.RevokeToken)
+
+		profile := v1.Group("/profile", s.apiGatewayAuthMiddleware, s.sessionTrackingMiddleware)
+		profile.GET("", s.handler.GetProfile)
+		profile.PUT("", s.handler.PutProfile)
+		profile.PATCH("", s.handler.PatchProfile)
+		profile.DELETE("", s.handler.DeleteProfile)
+		profile.POST("/password", s.handler.ChangePassword)
+		profile.POST("/email/verification", s.handler.RequestEmailVerification)
+		profile.POST("/phone/verification", s.handler.StartPhoneVerification)
+		profile.POST("/phone/verification/confirm", s.handler.ConfirmPhoneVerification)
+		profile.GET("/2fa", s.handler.GetTwoFactor)
+		profile.POST("/2fa", s.handler.EnrollTwoFactor)
+		profile.POST("/2fa/confirm", s.handler.ConfirmTwoFactor)
+		profile.POST("/2fa/disable", s.handler.DisableTwoFactor)
+		profile.POST("/2fa/recovery-codes", s.handler.RegenerateRecoveryCodes)
+		profile.GET("/anti-phishing-code", s.handler.GetAntiPhishingCode)
+		profile.PUT("/anti-phishing-code", s.handler.PutAntiPhishingCode)
+		profile.DELETE("/anti-phishing-code", s.handler.DeleteAntiPhishingCode)
+		profile.GET("/sessions", s.handler.ListSessions)
+		profile.DELETE("/sessions/:id", s.handler.DeleteSession)
+	}
+
+	ipcAuth := s.makeIPCMiddleware(s.cfg.InternalAPIUser, s.cfg.InternalAPIPassword)
+	s.GET("/metrics", echo.WrapHandler(s.metrics.Handler()), ipcAuth)
+	s.GET("/internal/swagger/*", echoSwagger.EchoWrapHandler(echoSwagger.InstanceName(internalSwaggerInstance)), ipcAuth)
+	s.GET("/internal/readyz", s.readyzDetails, ipcAuth)
+
+	internal := s.Group("/internal/v1", ipcAuth)
+	{
+		internal.GET("/profiles/lookup", s.handler.LookupProfile)
+		internal.POST("/profiles/bulk", s.handler.BulkProfiles)
+		internal.GET("/profiles/:id", s.handler.GetProfileByID)
+		internal.GET("/profiles/:id/verification", s.handler.GetVerificationStatus)
+		internal.GET("/profiles/:id/anti-phishing-code", s.handler.GetProfileAntiPhishingCode)
 	}
 }
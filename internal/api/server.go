@@ -1,15 +1,29 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sync/atomic"
+	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo-contrib/jaegertracing"
 	"github.com/labstack/echo/v4"
 
+	common "github.com/levongh/profile/common/config"
+	"github.com/levongh/profile/common/httpx"
 	"github.com/levongh/profile/internal/config"
+	"github.com/levongh/profile/internal/health"
 	"github.com/levongh/profile/internal/log"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/metrics"
+	"github.com/levongh/profile/internal/migration"
+	"github.com/levongh/profile/internal/otp"
+	"github.com/levongh/profile/internal/password"
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/sms"
+	"github.com/levongh/profile/internal/storage"
+	"github.com/levongh/profile/internal/token"
 )
 
 type Server struct {
@@ -18,41 +32,165 @@ type Server struct {
 	cfg     *config.Config
 	Logger  *log.Logger
 	handler Handler
-	// ss      *ServiceStorage
+	ss      *storage.ServiceStorage
 
+	health      *health.Registry
+	metrics     *metrics.Metrics
 	closeJaeger io.Closer
+	// shuttingDown is set to 1 once Shutdown is called, readiness fails from then on
+	shuttingDown int32
 }
 
 type Handler struct {
-	db     *sqlx.DB
+	cfg    *config.Config
+	ss     *storage.ServiceStorage
+	hasher *password.Hasher
+	mailer mail.Sender
+	otp    *otp.Service
+	cipher *secret.Cipher
+	tokens *token.Service
+	tasks  *backgroundTasks
 	logger *log.Logger
 }
 
 func NewServer(cfg *config.Config, logger *log.Logger) (*Server, error) {
-	var err error
+	cipher, err := secret.NewCipher(cfg.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := newServiceStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &Server{
 		Echo:   echo.New(),
 		cfg:    cfg,
 		Logger: logger,
+		ss:     ss,
+	}
+	s.health = s.newHealthRegistry(cfg, ss)
+	s.metrics = metrics.New()
+	if db := ss.DB(); db != nil {
+		s.metrics.RegisterDB(db.DB, "postgres")
 	}
 
 	s.handler = Handler{
-		db:     nil,
+		cfg: cfg,
+		ss:  ss,
+		hasher: password.NewHasher(password.Params{
+			Memory:      cfg.PasswordMemory,
+			Iterations:  cfg.PasswordIterations,
+			Parallelism: cfg.PasswordParallelism,
+			SaltLength:  password.DefaultParams.SaltLength,
+			KeyLength:   password.DefaultParams.KeyLength,
+		}),
+		mailer: mail.NewSMTPSender(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}),
+		otp: otp.NewService(otp.Config{
+			Length:         cfg.OTPLength,
+			TTL:            cfg.OTPTTL,
+			MaxAttempts:    cfg.OTPMaxAttempts,
+			ResendInterval: cfg.OTPResendInterval,
+			Key:            []byte(cfg.SecretKey),
+		}, ss.OTPCodes, newSMSSender(cfg, logger)),
+		cipher: cipher,
+		tokens: token.NewService(token.Config{
+			Issuer:      cfg.Host,
+			AccessTTL:   cfg.AccessTokenTTL,
+			RefreshTTL:  cfg.RefreshTokenTTL,
+			KeyRotation: cfg.SigningKeyRotation,
+		}, cipher, ss.SigningKeys, ss.RefreshTokens, ss.Profiles),
+		tasks:  newBackgroundTasks(),
 		logger: logger,
 	}
 
+	if cfg.Mode == common.ModeLocal {
+		if err := s.handler.seedLocalUser(context.Background()); err != nil {
+			_ = ss.Close()
+			return nil, fmt.Errorf("can't seed mock user: %w", err)
+		}
+		s.Use(s.localCORS())
+	}
+
+	s.HTTPErrorHandler = httpx.NewHTTPErrorHandler(s.reportError)
+
+	s.closeJaeger = jaegertracing.New(s.Echo, nil)
+	// registered after tracing to tag request spans with the id
+	s.Use(httpx.RequestIDMiddleware)
+	s.Use(log.EchoLoggingMiddleware(logger, skipLoggingFunc))
+	s.Use(s.metrics.Middleware)
+
 	s.initRoutes()
 	// s.initMidleware()
 
-	s.closeJaeger = jaegertracing.New(s.Echo, nil)
 	return s, err //TODO revisit
 }
 
-// func (s *Server) ServiceStorage() *ServiceStorage {
-// return s.ss
-// }
+// newServiceStorage falls back to in-memory storage only in local mode without STORAGE_DSN
+func newServiceStorage(cfg *config.Config) (*storage.ServiceStorage, error) {
+	if cfg.Mode == common.ModeLocal && cfg.StorageDSN == "" {
+		return storage.NewMemory(), nil
+	}
+
+	if err := migration.Ensure(cfg.StorageDSN, cfg.AutoMigrate); err != nil {
+		return nil, err
+	}
+	return storage.NewPostgres(cfg.StorageDSN)
+}
 
+func newSMSSender(cfg *config.Config, logger *log.Logger) sms.Sender {
+	if cfg.SMSProvider == sms.ProviderHTTP {
+		return sms.NewHTTPSender(sms.HTTPConfig{
+			URL:   cfg.SMSProviderURL,
+			Token: cfg.SMSProviderToken,
+			From:  cfg.SMSFrom,
+		})
+	}
+	return sms.NewLogSender(logger, cfg.SMSLogFile)
+}
+
+// reportError logs errors resulting in 5xx at error level, so they are sent to Sentry
+func (s *Server) reportError(c echo.Context, err error) {
+	s.Logger.WithContext(c.Request().Context()).Error("request failed",
+		log.Error(err),
+		log.String("method", c.Request().Method),
+		log.String("route", c.Path()),
+	)
+}
+
+func (s *Server) ServiceStorage() *storage.ServiceStorage {
+	return s.ss
+}
+
+// Shutdown fails readiness for ShutdownDelay, then stops accepting connections
+// and waits for in-flight requests and the work they started until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+
+	select {
+	case <-time.After(s.cfg.ShutdownDelay):
+	case <-ctx.Done():
+	}
+
+	if err := s.Echo.Shutdown(ctx); err != nil {
+		return err
+	}
+	return s.handler.tasks.Wait(ctx)
+}
+
+// IsShuttingDown reports whether Shutdown is called
+func (s *Server) IsShuttingDown() bool {
+	return atomic.LoadInt32(&s.shuttingDown) == 1
+}
+
+// Close releases resources in order: remaining connections, storage, Sentry events and tracer
 func (s *Server) Close() error {
 	var allErrors error
 
@@ -60,9 +198,13 @@ func (s *Server) Close() error {
 		allErrors = addError(allErrors, err)
 	}
 
-	// if err := s.ss.Close(); err != nil {
-	// 	allErrors = addError(allErrors, err)
-	// }
+	if err := s.ss.Close(); err != nil {
+		allErrors = addError(allErrors, err)
+	}
+
+	if err := s.Logger.Flush(); err != nil {
+		allErrors = addError(allErrors, err)
+	}
 
 	if err := s.closeJaeger.Close(); err != nil {
 		allErrors = addError(allErrors, err)
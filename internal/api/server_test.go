@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common "github.com/levongh/profile/common/config"
+	"github.com/levongh/profile/internal/config"
+	"github.com/levongh/profile/internal/health"
+	"github.com/levongh/profile/internal/log"
+	"github.com/levongh/profile/internal/mail"
+	"github.com/levongh/profile/internal/password"
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+	"github.com/levongh/profile/internal/token"
+)
+
+const (
+	testMockUserID    = "00000000-0000-4000-8000-000000000001"
+	testEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+)
+
+// fakeMailer keeps sent messages instead of delivering them
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// newTestServer returns local mode server backed by in-memory storage, routes are not registered
+func newTestServer(t *testing.T) *Server {
+	cfg := &config.Config{
+		Host:                     "http://localhost:8030",
+		ClientHost:               "http://localhost:3000",
+		Mode:                     common.ModeLocal,
+		MockUserID:               testMockUserID,
+		MockUserHeader:           "X-Mock-User",
+		MockUserEmail:            "local@profile.local",
+		MockUserPassword:         "Local-pa55word",
+		PasswordResetTTL:         time.Hour,
+		EmailVerificationTTL:     time.Hour,
+		PasswordResetMaxAttempts: 3,
+		LoginMaxAttempts:         10,
+		LoginIPMaxAttempts:       100,
+		LoginAttemptWindow:       time.Minute,
+		SecretKey:                strings.Repeat("s", 32),
+		EncryptionKey:            testEncryptionKey,
+		AccessTokenTTL:           time.Minute,
+		RefreshTokenTTL:          time.Hour,
+		SigningKeyRotation:       time.Hour,
+	}
+
+	cipher, err := secret.NewCipher(cfg.EncryptionKey)
+	require.NoError(t, err)
+	logger, err := log.NewLogger("profile", log.Err)
+	require.NoError(t, err)
+
+	ss := storage.NewMemory()
+	s := &Server{
+		Echo:   echo.New(),
+		cfg:    cfg,
+		Logger: logger,
+		ss:     ss,
+	}
+	s.handler = Handler{
+		cfg:    cfg,
+		ss:     ss,
+		hasher: password.NewHasher(password.Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}),
+		mailer: &fakeMailer{},
+		cipher: cipher,
+		tokens: token.NewService(token.Config{
+			Issuer:      cfg.Host,
+			AccessTTL:   cfg.AccessTokenTTL,
+			RefreshTTL:  cfg.RefreshTokenTTL,
+			KeyRotation: cfg.SigningKeyRotation,
+		}, cipher, ss.SigningKeys, ss.RefreshTokens, ss.Profiles),
+		tasks:  newBackgroundTasks(),
+		logger: logger,
+	}
+	return s
+}
+
+func TestShutdownFailsReadinessFirst(t *testing.T) {
+	s := newTestServer(t)
+	s.cfg.ShutdownDelay = 200 * time.Millisecond
+	s.health = health.NewRegistry(time.Hour, time.Second)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		require.NoError(t, s.readyz(s.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)))
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, readyz())
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+
+	require.Eventually(t, s.IsShuttingDown, time.Second, time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, readyz())
+	select {
+	case <-done:
+		t.Fatal("shutdown must wait for ShutdownDelay")
+	default:
+	}
+	assert.NoError(t, <-done)
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	s := newTestServer(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	s.GET("/slow", func(c echo.Context) error {
+		close(entered)
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.Listener = ln
+	s.HideBanner, s.HidePort = true, true
+	go func() { _ = s.Start("") }()
+	url := "http://" + ln.Addr().String() + "/slow"
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = res.Body.Close()
+		status <- res.StatusCode
+	}()
+	<-entered
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+	require.Eventually(t, s.IsShuttingDown, time.Second, time.Millisecond)
+	close(release)
+
+	assert.Equal(t, http.StatusOK, <-status, "in-flight request completes")
+	assert.NoError(t, <-done)
+	_, err = http.Get(url)
+	assert.Error(t, err, "new connections are refused")
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/pagination"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/storage"
+	"github.com/levongh/profile/internal/token"
+)
+
+const (
+	// headerDeviceID is a stable id of the client installation, generated by the client
+	headerDeviceID = "X-Device-Id"
+
+	contextKeySessionID = "sessionID"
+)
+
+type sessionResponse struct {
+	ID          string    `json:"id"`
+	Device      string    `json:"device"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	Location    string    `json:"location"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	// Current is true for the session the request is made from
+	Current bool `json:"current"`
+}
+
+type sessionsResponse struct {
+	Data  []sessionResponse `json:"data"`
+	Links pagination.Links  `json:"links"`
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description Returns devices the authenticated user is signed in from, most recently signed in first by default
+// @Tags sessions
+// @Produce json
+// @Param limit query int false "page size, 20 by default, up to 100"
+// @Param cursor query string false "cursor from links.next"
+// @Param offset query int false "number of sessions to skip, can't be used with cursor"
+// @Param order_by query string false "last_seen_at, first_seen_at or device, prefixed with - for descending order"
+// @Success 200 {object} sessionsResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Router /profile/sessions [get]
+func (h *Handler) ListSessions(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	page, res := pagination.Parse(c.QueryParams(), storage.SessionsPage)
+	if !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	sessions, err := h.ss.Sessions.List(c.Request().Context(), userID, page)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	n, cursor := page.Next(len(sessions), func(i int) (string, string) {
+		return sessions[i].PageKey(page.Column())
+	})
+
+	current := currentSessionID(c)
+	out := sessionsResponse{
+		Data:  make([]sessionResponse, 0, n),
+		Links: pagination.Links{Next: pagination.NextLink(c.Request().URL, cursor)},
+	}
+	for _, s := range sessions[:n] {
+		out.Data = append(out.Data, sessionResponse{
+			ID:          s.ID,
+			Device:      s.Device,
+			IPAddress:   s.IPAddress,
+			UserAgent:   s.UserAgent,
+			Location:    s.Location,
+			FirstSeenAt: s.FirstSeenAt,
+			LastSeenAt:  s.LastSeenAt,
+			Current:     s.ID == current,
+		})
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+// DeleteSession godoc
+// @Summary Revoke session
+// @Description Signs the device out
+// @Tags sessions
+// @Param id path string true "session id"
+// @Success 204
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Router /profile/sessions/{id} [delete]
+func (h *Handler) DeleteSession(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return httpx.JSONErr(c, err, http.StatusNotFound, validation.SessionNotFound())
+	}
+
+	// tokens are revoked first, so the device can't refresh them after the session is gone
+	if err := h.tokens.RevokeSession(ctx, userID, sessionID); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	// the device stays signed out, sessionTrackingMiddleware rejects access tokens issued for
+	// the session and requests from the device until it signs in again
+	err = h.ss.Sessions.Revoke(ctx, userID, sessionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusNotFound, validation.SessionNotFound())
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditSessionRevoked)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// touchSession records the request in its session and returns the session id. Requests with
+// access token issued for a session are tracked by it, the others by the device, they are not
+// tracked without valid device id or address.
+// storage.ErrSessionRevoked is returned for the session which was signed out
+func (h *Handler) touchSession(c echo.Context, profileID string, claims *token.Claims) (string, error) {
+	ctx := c.Request().Context()
+	if claims != nil && claims.SessionID != "" {
+		session := describeRequest(c, h.cfg.SessionLocationHeader, profileID)
+		session.ID = claims.SessionID
+		err := h.ss.Sessions.TouchByID(ctx, session)
+		if errors.Is(err, storage.ErrSessionRevoked) {
+			return "", err
+		}
+		if err != nil {
+			h.logger.WithContext(ctx).Errorf("failed to track session of %s: %s", profileID, err)
+		}
+		return claims.SessionID, nil
+	}
+
+	session := h.requestSession(c, profileID)
+	if session == nil {
+		return "", nil
+	}
+
+	err := h.ss.Sessions.Touch(ctx, session)
+	if errors.Is(err, storage.ErrSessionRevoked) {
+		return "", err
+	}
+	if err != nil {
+		h.logger.WithContext(ctx).Errorf("failed to track session of %s: %s", profileID, err)
+		return "", nil
+	}
+	return session.ID, nil
+}
+
+// tokenClaims returns claims of the access token of the request, it is nil for requests without
+// token and the tokens not issued by the service when the API gateway is trusted
+func (h *Handler) tokenClaims(c echo.Context) *token.Claims {
+	accessToken, _ := c.Get(httpx.AccessTokenKey.String()).(string)
+	if accessToken == "" {
+		return nil
+	}
+	claims, err := h.tokens.Parse(c.Request().Context(), accessToken)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
+// startSession records the device signing in, its session is restored if it was revoked
+func (h *Handler) startSession(c echo.Context, profileID string) string {
+	session := h.requestSession(c, profileID)
+	if session == nil {
+		return ""
+	}
+
+	ctx := c.Request().Context()
+	if err := h.ss.Sessions.Start(ctx, session); err != nil {
+		h.logger.WithContext(ctx).Errorf("failed to start session of %s: %s", profileID, err)
+		return ""
+	}
+	return session.ID
+}
+
+// requestSession describes the device of the request, it is nil without valid device id or address
+func (h *Handler) requestSession(c echo.Context, profileID string) *storage.Session {
+	session := describeRequest(c, h.cfg.SessionLocationHeader, profileID)
+	if res := validation.ValidateIPAddressAndDevice(session.IPAddress, session.Device); !res.IsValid() {
+		return nil
+	}
+	return session
+}
+
+func describeRequest(c echo.Context, locationHeader, profileID string) *storage.Session {
+	req := c.Request()
+	return &storage.Session{
+		ProfileID: profileID,
+		Device:    strings.TrimSpace(req.Header.Get(headerDeviceID)),
+		IPAddress: c.RealIP(),
+		UserAgent: req.UserAgent(),
+		Location:  req.Header.Get(locationHeader),
+	}
+}
+
+// currentSessionID returns id of the session resolved by sessionTrackingMiddleware,
+// it is empty if the request isn't tracked
+func currentSessionID(c echo.Context) string {
+	id, _ := c.Get(contextKeySessionID).(string)
+	return id
+}
+
+// revokeSessions signs out all devices of the profile except the given session
+func (h *Handler) revokeSessions(ctx context.Context, profileID, exceptID string) error {
+	if err := h.tokens.RevokeProfile(ctx, profileID, exceptID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := h.ss.Sessions.RevokeAll(ctx, profileID, exceptID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
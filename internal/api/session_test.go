@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common "github.com/levongh/profile/common/config"
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/internal/config"
+)
+
+const testDevice = "laptop"
+
+// newUserContext returns context of the request the mock user makes from testDevice
+func newUserContext(s *Server, method, body string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(headerDeviceID, testDevice)
+	rec := httptest.NewRecorder()
+
+	c := s.NewContext(req, rec)
+	c.Set(httpx.ContextKeyUserID.String(), testMockUserID)
+	return c, rec
+}
+
+func requestToken(t *testing.T, s *Server, body string) (*tokenResponse, int) {
+	c, rec := newUserContext(s, http.MethodPost, body)
+	require.NoError(t, s.handler.Token(c))
+	if rec.Code != http.StatusOK {
+		return nil, rec.Code
+	}
+
+	var out tokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return &out, rec.Code
+}
+
+func signIn(t *testing.T, s *Server, password string) *tokenResponse {
+	pair, status := requestToken(t, s, fmt.Sprintf(`{"grant_type":"password","email":%q,"password":%q}`, s.cfg.MockUserEmail, password))
+	require.Equal(t, http.StatusOK, status)
+	return pair
+}
+
+func refresh(t *testing.T, s *Server, refreshToken string) (*tokenResponse, int) {
+	return requestToken(t, s, fmt.Sprintf(`{"grant_type":"refresh_token","refresh_token":%q}`, refreshToken))
+}
+
+// trackSession passes the request through sessionTrackingMiddleware and returns the resolved session
+func trackSession(t *testing.T, s *Server) (string, int) {
+	c, rec := newUserContext(s, http.MethodGet, "")
+	var sessionID string
+	err := s.sessionTrackingMiddleware(func(c echo.Context) error {
+		sessionID = currentSessionID(c)
+		return c.NoContent(http.StatusOK)
+	})(c)
+	require.NoError(t, err)
+	return sessionID, rec.Code
+}
+
+func TestDeleteSessionKeepsDeviceSignedOut(t *testing.T) {
+	s := newTestServer(t)
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	pair := signIn(t, s, s.cfg.MockUserPassword)
+
+	sessionID, status := trackSession(t, s)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, sessionID)
+
+	c, rec := newUserContext(s, http.MethodDelete, "")
+	c.SetParamNames("id")
+	c.SetParamValues(sessionID)
+	require.NoError(t, s.handler.DeleteSession(c))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	for i := 0; i < 2; i++ {
+		_, status = trackSession(t, s)
+		assert.Equal(t, http.StatusUnauthorized, status, "requests from the device don't restore the session")
+	}
+	_, status = refresh(t, s, pair.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	signIn(t, s, s.cfg.MockUserPassword)
+	_, status = trackSession(t, s)
+	assert.Equal(t, http.StatusOK, status, "signing in again restores the session")
+}
+
+// serveWithToken passes the request with the access token through the profile API middlewares
+// and returns its status
+func serveWithToken(t *testing.T, s *Server, accessToken, device string) int {
+	return serveAuthenticated(t, s, accessToken, device, "", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+}
+
+// serveAuthenticated serves the request to the profile API handler the way routes do it
+func serveAuthenticated(t *testing.T, s *Server, accessToken, device, body string, handler echo.HandlerFunc) int {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(httpx.HeaderAuthorization, tokenTypeBearer+" "+accessToken)
+	if device != "" {
+		req.Header.Set(headerDeviceID, device)
+	}
+	rec := httptest.NewRecorder()
+
+	err := s.apiGatewayAuthMiddleware(s.sessionTrackingMiddleware(handler))(s.NewContext(req, rec))
+	require.NoError(t, err)
+	return rec.Code
+}
+
+// signInFrom signs the mock user in from the device
+func signInFrom(t *testing.T, s *Server, device, password string) *tokenResponse {
+	c, rec := newUserContext(s, http.MethodPost, fmt.Sprintf(`{"grant_type":"password","email":%q,"password":%q}`, s.cfg.MockUserEmail, password))
+	c.Request().Header.Set(headerDeviceID, device)
+	require.NoError(t, s.handler.Token(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var out tokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return &out
+}
+
+// newJWTTestServer returns test server which authenticates the profile API with access tokens
+func newJWTTestServer(t *testing.T) *Server {
+	s := newTestServer(t)
+	s.cfg.Mode = common.ModeProd
+	s.cfg.AuthTrustMode = config.AuthTrustJWT
+	require.NoError(t, s.handler.seedLocalUser(context.Background()))
+	return s
+}
+
+func TestDeleteSessionRejectsItsAccessTokens(t *testing.T) {
+	s := newJWTTestServer(t)
+	pair := signIn(t, s, s.cfg.MockUserPassword)
+	other := signIn(t, s, s.cfg.MockUserPassword)
+
+	require.Equal(t, http.StatusOK, serveWithToken(t, s, pair.AccessToken, ""), "tracked by the token without device id")
+	sessionID, status := trackSession(t, s)
+	require.Equal(t, http.StatusOK, status)
+
+	c, rec := newUserContext(s, http.MethodDelete, "")
+	c.SetParamNames("id")
+	c.SetParamValues(sessionID)
+	require.NoError(t, s.handler.DeleteSession(c))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	for _, device := range []string{testDevice, "", "tablet"} {
+		assert.Equal(t, http.StatusUnauthorized, serveWithToken(t, s, pair.AccessToken, device), "device %q", device)
+		assert.Equal(t, http.StatusUnauthorized, serveWithToken(t, s, other.AccessToken, device), "device %q", device)
+	}
+
+	renewed := signIn(t, s, s.cfg.MockUserPassword)
+	assert.Equal(t, http.StatusOK, serveWithToken(t, s, renewed.AccessToken, ""))
+}
+
+func TestErrorsInTokenLocale(t *testing.T) {
+	s := newJWTTestServer(t)
+	ctx := context.Background()
+	p, err := s.ss.Profiles.Get(ctx, testMockUserID)
+	require.NoError(t, err)
+	p.Locale = "ru"
+	require.NoError(t, s.ss.Profiles.Save(ctx, p))
+	pair := signIn(t, s, s.cfg.MockUserPassword)
+
+	var lang string
+	status := serveAuthenticated(t, s, pair.AccessToken, "", "", func(c echo.Context) error {
+		lang = httpx.Language(c)
+		return c.NoContent(http.StatusOK)
+	})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ru", lang, "the locale is taken from the token")
+}
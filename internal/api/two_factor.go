@@ -0,0 +1,477 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/common/validation"
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+	"github.com/levongh/profile/internal/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+)
+
+type twoFactorResponse struct {
+	Enabled           bool   `json:"enabled"`
+	Method            string `json:"method,omitempty"`
+	RecoveryCodesLeft int    `json:"recovery_codes_left"`
+}
+
+type enrollTwoFactorRequest struct {
+	Method string `json:"method" validate:"two_factor_method=totp"`
+}
+
+func (r *enrollTwoFactorRequest) Validate() *validation.Result {
+	return validation.NewResult()
+}
+
+type enrollTwoFactorResponse struct {
+	// Secret is base32 encoded key for manual entry
+	Secret string `json:"secret"`
+	// URI is otpauth:// key URI to be rendered as QR code
+	URI string `json:"uri"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *twoFactorCodeRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Code == "" {
+		out.AddFieldError(fieldCode, validation.InvalidCode())
+	}
+	return out
+}
+
+type recoveryCodesResponse struct {
+	// RecoveryCodes are shown only once, each of them can be used instead of a TOTP code once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type disableTwoFactorRequest struct {
+	Password string `json:"password" normalize:"-"`
+	// Code is either TOTP or recovery code
+	Code string `json:"code"`
+}
+
+func (r *disableTwoFactorRequest) Validate() *validation.Result {
+	out := validation.NewResult()
+	if r.Password == "" {
+		out.AddFieldError(fieldPassword, validation.EmptyPassword())
+	}
+	if r.Code == "" {
+		out.AddFieldError(fieldCode, validation.InvalidCode())
+	}
+	return out
+}
+
+// GetTwoFactor godoc
+// @Summary Get two-factor authentication status
+// @Tags 2fa
+// @Produce json
+// @Success 200 {object} twoFactorResponse
+// @Failure 401 {object} validation.Result
+// @Router /profile/2fa [get]
+func (h *Handler) GetTwoFactor(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	ctx := c.Request().Context()
+
+	tf, err := h.ss.TwoFactor.Get(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.JSON(http.StatusOK, twoFactorResponse{})
+	}
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !tf.Enabled() {
+		return c.JSON(http.StatusOK, twoFactorResponse{})
+	}
+
+	left, err := h.ss.TwoFactor.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.JSON(http.StatusOK, twoFactorResponse{
+		Enabled:           true,
+		Method:            tf.Method,
+		RecoveryCodesLeft: left,
+	})
+}
+
+// EnrollTwoFactor godoc
+// @Summary Enroll two-factor authentication
+// @Description Generates TOTP key, it is enabled only after the first code is confirmed. Enrolling again replaces the pending key
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body enrollTwoFactorRequest true "method"
+// @Success 200 {object} enrollTwoFactorResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 404 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Router /profile/2fa [post]
+func (h *Handler) EnrollTwoFactor(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req enrollTwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	p, err := h.ss.Profiles.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+
+	tf, err := h.ss.TwoFactor.Get(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if tf != nil && tf.Enabled() {
+		return httpx.JSONErr(c, nil, http.StatusConflict, validation.TwoFactorAlreadyEnabled())
+	}
+
+	key, err := totp.NewSecret()
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	encrypted, err := h.cipher.Encrypt([]byte(key))
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+
+	err = h.ss.TwoFactor.Save(ctx, &storage.TwoFactor{
+		ProfileID:       userID,
+		Method:          req.Method,
+		SecretEncrypted: encrypted,
+	})
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	return c.JSON(http.StatusOK, enrollTwoFactorResponse{
+		Secret: key,
+		URI:    totp.URI(h.cfg.TOTPIssuer, accountName(p), key),
+	})
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor authentication
+// @Description Enables enrolled TOTP key after checking the first code, returns recovery codes
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body twoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Router /profile/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req twoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	tf, err := h.ss.TwoFactor.Get(ctx, userID)
+	if err != nil {
+		return twoFactorLoadErr(c, err)
+	}
+	if tf.Enabled() {
+		return httpx.JSONErr(c, nil, http.StatusConflict, validation.TwoFactorAlreadyEnabled())
+	}
+
+	ok, err := h.verifyTOTP(ctx, tf, req.Code)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		return invalidSecondFactor(c)
+	}
+
+	now := time.Now().UTC()
+	tf.ConfirmedAt = &now
+	if err := h.ss.TwoFactor.Save(ctx, tf); err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+
+	codes, err := h.newRecoveryCodes(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditTwoFactorEnabled)
+
+	return c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Requires the password and either TOTP or recovery code
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body disableTwoFactorRequest true "password and code"
+// @Success 204
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /profile/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req disableTwoFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	tf, err := h.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return twoFactorLoadErr(c, err)
+	}
+
+	ok, err := h.countProfileAttempt(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !ok {
+		return tooManyAttempts(c)
+	}
+
+	cred, err := h.ss.Credentials.Get(ctx, userID)
+	if err != nil {
+		return profileLoadErr(c, err)
+	}
+	ok, err = h.verifyPassword(ctx, cred, req.Password)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		res := validation.NewResult().AddFieldError(fieldPassword, validation.WrongPassword())
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ok, err = h.verifySecondFactor(c, tf, req.Code)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		return invalidSecondFactor(c)
+	}
+
+	if err := h.ss.TwoFactor.Delete(ctx, userID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditTwoFactorDisabled)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes, requires TOTP code
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body twoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400 {object} validation.Result
+// @Failure 401 {object} validation.Result
+// @Failure 409 {object} validation.Result
+// @Failure 429 {object} validation.Result
+// @Router /profile/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
+
+	var req twoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return httpx.JSONErr(c, err, http.StatusBadRequest, validation.UnmarshalDetailedError(err))
+	}
+	if res := validation.Validate(&req); !res.IsValid() {
+		return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+	}
+
+	ctx := c.Request().Context()
+
+	tf, err := h.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return twoFactorLoadErr(c, err)
+	}
+
+	ok, err := h.countProfileAttempt(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	if !ok {
+		return tooManyAttempts(c)
+	}
+
+	ok, err = h.verifyTOTP(ctx, tf, req.Code)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, nil)
+	}
+	if !ok {
+		return invalidSecondFactor(c)
+	}
+
+	codes, err := h.newRecoveryCodes(ctx, userID)
+	if err != nil {
+		return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+	}
+	h.audit(c, userID, storage.AuditRecoveryCodesNew)
+
+	return c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// enabledTwoFactor returns confirmed second factor of the profile, pending
+// enrollment is reported as storage.ErrNotFound
+func (h *Handler) enabledTwoFactor(ctx context.Context, profileID string) (*storage.TwoFactor, error) {
+	tf, err := h.ss.TwoFactor.Get(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled() {
+		return nil, storage.ErrNotFound
+	}
+	return tf, nil
+}
+
+// twoFactorLoadErr responds to a failed lookup of the second factor
+func twoFactorLoadErr(c echo.Context, err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return httpx.JSONErr(c, err, http.StatusConflict, validation.TwoFactorNotEnabled())
+	}
+	return httpx.JSONErr(c, err, http.StatusInternalServerError, validation.DBOperationError(err))
+}
+
+// countProfileAttempt limits checks of the password or second factor made by the signed in user,
+// every check counts, so a stolen session can't be used to guess them
+func (h *Handler) countProfileAttempt(ctx context.Context, profileID string) (bool, error) {
+	return h.countAttempt(ctx, attemptLimit{key: attemptKey(attemptKeyProfile, profileID), max: h.cfg.LoginMaxAttempts})
+}
+
+// verifySecondFactor accepts either TOTP or one of the recovery codes, each of them only once
+func (h *Handler) verifySecondFactor(c echo.Context, tf *storage.TwoFactor, code string) (bool, error) {
+	if len(code) == totp.Digits {
+		return h.verifyTOTP(c.Request().Context(), tf, code)
+	}
+
+	hash := secret.HMAC([]byte(h.cfg.SecretKey), secret.NormalizeRecoveryCode(code))
+	err := h.ss.TwoFactor.UseRecoveryCode(c.Request().Context(), tf.ProfileID, hash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	h.audit(c, tf.ProfileID, storage.AuditRecoveryCodeUsed)
+	return true, nil
+}
+
+// verifyTOTP checks the code and marks its time step as used, so it can't be replayed
+func (h *Handler) verifyTOTP(ctx context.Context, tf *storage.TwoFactor, code string) (bool, error) {
+	key, err := h.cipher.Decrypt(tf.SecretEncrypted)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := totp.Validate(string(key), code, time.Now())
+	if !ok || step <= tf.LastUsedStep {
+		return false, nil
+	}
+
+	err = h.ss.TwoFactor.UseStep(ctx, tf.ProfileID, step)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tf.LastUsedStep = step
+	return true, nil
+}
+
+// newRecoveryCodes replaces recovery codes of the profile, only their hashes are stored
+func (h *Handler) newRecoveryCodes(ctx context.Context, profileID string) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		code, err := secret.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = secret.HMAC([]byte(h.cfg.SecretKey), secret.NormalizeRecoveryCode(code))
+	}
+
+	if err := h.ss.TwoFactor.ReplaceRecoveryCodes(ctx, profileID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func invalidSecondFactor(c echo.Context) error {
+	res := validation.NewResult().AddFieldError(fieldCode, validation.InvalidCode())
+	return httpx.JSONErr(c, nil, http.StatusBadRequest, res)
+}
+
+// accountName identifies the profile in authenticator apps
+func accountName(p *storage.Profile) string {
+	switch {
+	case p.Email != nil:
+		return *p.Email
+	case p.Phone != nil:
+		if p.CountryCallingCode != nil {
+			return "+" + *p.CountryCallingCode + *p.Phone
+		}
+		return *p.Phone
+	default:
+		return p.ID
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
@@ -18,10 +20,87 @@ type Config struct {
 	Mode        string    `envconfig:"MODE" validate:"required,oneof='local' 'development' 'staging' 'production'"`
 	ServiceName string    `envconfig:"SERVICE_NAME" validate:"required"`
 	LogLevel    log.Level `envconfig:"LOG_LEVEL"`
-	StorageDSN  string    `envconfig:"STORAGE_DSN" validate:"required,uri"`
+	// SentryDSN enables reporting of errors to Sentry
+	SentryDSN string `envconfig:"SENTRY_DSN" validate:"omitempty,url"`
+	// on SIGINT or SIGTERM readiness fails for ShutdownDelay so load balancers stop routing,
+	// then in-flight requests are drained for up to ShutdownTimeout. The delay should exceed
+	// the period load balancers probe readiness with
+	ShutdownDelay   time.Duration `envconfig:"SHUTDOWN_DELAY" default:"5s"`
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
+	// readiness report is cached for HealthCacheTTL, each dependency check is limited by HealthCheckTimeout
+	HealthCacheTTL     time.Duration `envconfig:"HEALTH_CACHE_TTL" default:"2s"`
+	HealthCheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	// StorageDSN can be omitted in local mode to keep everything in memory
+	StorageDSN string `envconfig:"STORAGE_DSN" validate:"required_unless=Mode local,omitempty,uri"`
+	// AutoMigrate applies embedded migrations on start, otherwise server refuses
+	// to start until the schema is migrated with `profile migrate up`
+	AutoMigrate bool `envconfig:"AUTO_MIGRATE"`
+
+	// local mode only: the profile API is called on behalf of MockUserID unless MockUserHeader
+	// names another user, the mock user is seeded on start so it can sign in with MockUserPassword
+	MockUserID       string `envconfig:"MOCK_USER_ID" default:"00000000-0000-4000-8000-000000000001" validate:"uuid"`
+	MockUserHeader   string `envconfig:"MOCK_USER_HEADER" default:"X-Mock-User"`
+	MockUserEmail    string `envconfig:"MOCK_USER_EMAIL" default:"local@profile.local" validate:"email"`
+	MockUserPassword string `envconfig:"MOCK_USER_PASSWORD" default:"Local-pa55word"`
 
 	InternalAPIUser     string `envconfig:"INTERNAL_API_USER" validate:"required"`
 	InternalAPIPassword string `envconfig:"INTERNAL_API_PASSWORD" validate:"required"`
+
+	SMTPHost     string `envconfig:"SMTP_HOST" validate:"required"`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"25"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	MailFrom     string `envconfig:"MAIL_FROM" validate:"required,email"`
+
+	EmailVerificationTTL time.Duration `envconfig:"EMAIL_VERIFICATION_TTL" default:"24h"`
+	PasswordResetTTL     time.Duration `envconfig:"PASSWORD_RESET_TTL" default:"1h"`
+	// reset links sent per email within LoginAttemptWindow, requests count against LoginIPMaxAttempts too
+	PasswordResetMaxAttempts int `envconfig:"PASSWORD_RESET_MAX_ATTEMPTS" default:"3" validate:"min=1"`
+
+	// SMSProvider is either 'log' to only log messages or 'http' to post them to SMSProviderURL,
+	// only 'http' is allowed outside of local mode
+	SMSProvider      string `envconfig:"SMS_PROVIDER" default:"log" validate:"oneof=log http"`
+	SMSLogFile       string `envconfig:"SMS_LOG_FILE"`
+	SMSProviderURL   string `envconfig:"SMS_PROVIDER_URL" validate:"required_if=SMSProvider http,omitempty,url"`
+	SMSProviderToken string `envconfig:"SMS_PROVIDER_TOKEN"`
+	SMSFrom          string `envconfig:"SMS_FROM"`
+
+	OTPLength         int           `envconfig:"OTP_LENGTH" default:"6" validate:"min=4,max=10"`
+	OTPTTL            time.Duration `envconfig:"OTP_TTL" default:"5m"`
+	OTPMaxAttempts    int           `envconfig:"OTP_MAX_ATTEMPTS" default:"5" validate:"min=1"`
+	OTPResendInterval time.Duration `envconfig:"OTP_RESEND_INTERVAL" default:"1m"`
+
+	// sign in attempts are limited per login and per client IP within LoginAttemptWindow
+	LoginMaxAttempts   int           `envconfig:"LOGIN_MAX_ATTEMPTS" default:"10" validate:"min=1"`
+	LoginIPMaxAttempts int           `envconfig:"LOGIN_IP_MAX_ATTEMPTS" default:"100" validate:"min=1"`
+	LoginAttemptWindow time.Duration `envconfig:"LOGIN_ATTEMPT_WINDOW" default:"15m"`
+
+	// argon2id parameters, changing them upgrades stored hashes on the next successful login
+	PasswordMemory      uint32 `envconfig:"PASSWORD_ARGON2_MEMORY" default:"65536" validate:"min=19456"`
+	PasswordIterations  uint32 `envconfig:"PASSWORD_ARGON2_ITERATIONS" default:"3" validate:"min=1"`
+	PasswordParallelism uint8  `envconfig:"PASSWORD_ARGON2_PARALLELISM" default:"2" validate:"min=1"`
+
+	// SecretKey is used to hash short-lived secrets like OTP codes
+	SecretKey string `envconfig:"SECRET_KEY" validate:"required,min=32"`
+	// EncryptionKey is hex encoded AES-256 key for secrets stored in a recoverable form, e.g. TOTP keys
+	EncryptionKey string `envconfig:"ENCRYPTION_KEY" validate:"required,hexadecimal,len=64"`
+
+	// SessionLocationHeader is set by the API gateway with the location resolved from the client IP
+	SessionLocationHeader string `envconfig:"SESSION_LOCATION_HEADER" default:"X-Geo-Location"`
+
+	// access tokens are signed by keys rotated every SigningKeyRotation, public keys are served as JWKS,
+	// a new key is published some minutes before signing with it, so rotation can't be too frequent
+	AccessTokenTTL     time.Duration `envconfig:"ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL    time.Duration `envconfig:"REFRESH_TOKEN_TTL" default:"720h"`
+	SigningKeyRotation time.Duration `envconfig:"SIGNING_KEY_ROTATION" default:"720h" validate:"min=1h"`
+
+	// AuthTrustMode selects how callers of the profile API are authenticated:
+	// gateway trusts X-User-Id set by the API gateway, jwt verifies Bearer access token,
+	// both verifies the token if present and falls back to the header otherwise
+	AuthTrustMode string `envconfig:"AUTH_TRUST_MODE" default:"gateway" validate:"oneof=gateway jwt both"`
+
+	// TOTPIssuer is shown in authenticator apps next to the account name
+	TOTPIssuer string `envconfig:"TOTP_ISSUER" default:"Profile"`
 }
 
 func Read() (*Config, error) {
@@ -35,6 +114,9 @@ func Read() (*Config, error) {
 	if err := validate.Struct(cfg); err != nil {
 		return nil, err
 	}
+	if cfg.Mode != common.ModeLocal && cfg.SMSProvider != SMSProviderHTTP {
+		return nil, fmt.Errorf("SMS_PROVIDER must be %s in %s mode", SMSProviderHTTP, cfg.Mode)
+	}
 
 	return &cfg, nil
 }
@@ -44,6 +126,16 @@ func (c Config) HostWithoutProtocol() string {
 	return strings.TrimPrefix(wo, "https://")
 }
 
+// SMSProviderHTTP is the only SMS provider delivering messages, see sms.ProviderHTTP
+const SMSProviderHTTP = "http"
+
+// auth trust modes
+const (
+	AuthTrustGateway = "gateway"
+	AuthTrustJWT     = "jwt"
+	AuthTrustBoth    = "both"
+)
+
 func (c Config) IsNoop() bool {
 	return c.Mode == common.ModeDev
 }
@@ -0,0 +1,172 @@
+// Package health runs dependency checks for readiness probes
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// check statuses, a failed optional check is reported as warn and doesn't fail readiness
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Checker reports whether a dependency is usable, it must return once ctx is done
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result of a single check
+type Result struct {
+	Status string `json:"status"`
+	// Latency is duration of the check in milliseconds
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is readiness breakdown per dependency
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// OK reports whether all required checks passed
+func (r *Report) OK() bool {
+	return r.Status != StatusFail
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	optional bool
+}
+
+// Registry runs registered checks concurrently and caches the report for ttl,
+// so frequent probes don't load the dependencies
+type Registry struct {
+	ttl     time.Duration
+	timeout time.Duration
+	checks  []check
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewRegistry returns registry which caches reports for ttl, each check is limited by timeout
+func NewRegistry(ttl, timeout time.Duration) *Registry {
+	return &Registry{ttl: ttl, timeout: timeout}
+}
+
+// Register adds a check which fails readiness, it is not safe to call after checks started
+func (r *Registry) Register(name string, c Checker) {
+	r.checks = append(r.checks, check{name: name, checker: c})
+}
+
+// RegisterOptional adds a check which is reported but doesn't fail readiness,
+// e.g. a provider only some requests depend on
+func (r *Registry) RegisterOptional(name string, c Checker) {
+	r.checks = append(r.checks, check{name: name, checker: c, optional: true})
+}
+
+// Check returns the cached report or runs all checks if it is older than ttl,
+// concurrent callers wait for the same run. Checks aren't bound to a caller's request,
+// so a probe which went away doesn't get its cancellation cached as a failure
+func (r *Registry) Check() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.report != nil && time.Since(r.report.CheckedAt) < r.ttl {
+		return *r.report
+	}
+
+	report := r.run()
+	r.report = &report
+	return report
+}
+
+func (r *Registry) run() Report {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	results := make([]Result, len(r.checks))
+	var wg sync.WaitGroup
+	for i := range r.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, r.checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]Result, len(r.checks)),
+	}
+	for i, c := range r.checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		if res.Status == StatusFail {
+			report.Status = StatusFail
+		} else if res.Status == StatusWarn && report.Status == StatusOK {
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, c check) Result {
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	res := Result{
+		Status:  StatusOK,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err == nil {
+		return res
+	}
+
+	res.Status = StatusFail
+	if c.optional {
+		res.Status = StatusWarn
+	}
+	res.Error = err.Error()
+	if errors.Is(err, context.DeadlineExceeded) {
+		res.Error = "timeout"
+	}
+	return res
+}
+
+// Dial checks that a TCP connection to addr can be established
+func Dial(addr string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// Resolve checks that host name can be resolved, it is used for UDP endpoints which can't be dialed
+func Resolve(host string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		return err
+	})
+}
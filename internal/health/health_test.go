@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	failing := CheckerFunc(func(context.Context) error { return errors.New("down") })
+	passing := CheckerFunc(func(context.Context) error { return nil })
+	hanging := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	testCases := []struct {
+		name       string
+		register   func(r *Registry)
+		wantStatus string
+		wantChecks map[string]Result
+	}{
+		{
+			name:       "no checks",
+			register:   func(*Registry) {},
+			wantStatus: StatusOK,
+			wantChecks: map[string]Result{},
+		},
+		{
+			name: "all pass",
+			register: func(r *Registry) {
+				r.Register("db", passing)
+				r.RegisterOptional("smtp", passing)
+			},
+			wantStatus: StatusOK,
+			wantChecks: map[string]Result{"db": {Status: StatusOK}, "smtp": {Status: StatusOK}},
+		},
+		{
+			name: "optional fails",
+			register: func(r *Registry) {
+				r.Register("db", passing)
+				r.RegisterOptional("smtp", failing)
+			},
+			wantStatus: StatusWarn,
+			wantChecks: map[string]Result{"db": {Status: StatusOK}, "smtp": {Status: StatusWarn, Error: "down"}},
+		},
+		{
+			name: "required fails",
+			register: func(r *Registry) {
+				r.Register("db", failing)
+				r.RegisterOptional("smtp", failing)
+			},
+			wantStatus: StatusFail,
+			wantChecks: map[string]Result{"db": {Status: StatusFail, Error: "down"}, "smtp": {Status: StatusWarn, Error: "down"}},
+		},
+		{
+			name: "timeout",
+			register: func(r *Registry) {
+				r.Register("db", hanging)
+			},
+			wantStatus: StatusFail,
+			wantChecks: map[string]Result{"db": {Status: StatusFail, Error: "timeout"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRegistry(time.Second, 10*time.Millisecond)
+			tc.register(r)
+
+			report := r.Check()
+			assert.Equal(t, tc.wantStatus, report.Status)
+			assert.Equal(t, tc.wantStatus != StatusFail, report.OK())
+
+			for name := range report.Checks {
+				res := report.Checks[name]
+				res.Latency = 0
+				report.Checks[name] = res
+			}
+			assert.Equal(t, tc.wantChecks, report.Checks)
+		})
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	var calls int32
+	r := NewRegistry(50*time.Millisecond, time.Second)
+	r.Register("db", CheckerFunc(func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}))
+
+	r.Check()
+	r.Check()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	time.Sleep(60 * time.Millisecond)
+	r.Check()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+
+	assert.NoError(t, Dial(addr).Check(context.Background()))
+
+	require.NoError(t, ln.Close())
+	assert.Error(t, Dial(addr).Check(context.Background()))
+}
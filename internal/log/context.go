@@ -0,0 +1,19 @@
+package log
+
+import (
+	"context"
+
+	"github.com/levongh/profile/common/httpx"
+)
+
+const fieldRequestID = "request_id"
+
+// WithContext returns the logger annotated with request id of ctx,
+// the logger itself is returned if ctx doesn't belong to a request
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	id := httpx.GetRequestID(ctx)
+	if id == "" {
+		return l
+	}
+	return l.AddField(fieldRequestID, id)
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/levongh/profile/common/httpx"
+)
+
+// EchoLoggingMiddleware writes an access log entry per request. Responses with 4xx and 5xx
+// statuses also carry the original error and the response body stored by httpx.JSONErr.
+// Requests matching skip are not logged, skip can be nil
+func EchoLoggingMiddleware(logger *Logger, skip func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skip != nil && skip(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// let the error handler write the response, so its status is logged, the error
+				// isn't returned as it is handled already
+				c.Error(err)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			fields := []Field{
+				String("method", req.Method),
+				String("route", c.Path()),
+				String("uri", req.RequestURI),
+				Int("status", res.Status),
+				Any("latency", time.Since(start)),
+				Any("bytes_in", req.ContentLength),
+				Any("bytes_out", res.Size),
+				String("ip", c.RealIP()),
+			}
+			if userID, ok := c.Get(httpx.ContextKeyUserID.String()).(string); ok && userID != "" {
+				fields = append(fields, String("user_id", userID))
+			}
+
+			logger := logger.WithContext(req.Context())
+			if res.Status < 400 {
+				logger.Info("request", fields...)
+				return nil
+			}
+
+			original, _ := c.Get(httpx.EchoContextKeyOriginalError).(error)
+			if original == nil {
+				original = err
+			}
+			if original != nil {
+				fields = append(fields, Error(original))
+			}
+			if body := c.Get(httpx.EchoContextKeyResponseBody); body != nil {
+				fields = append(fields, Reflect("response", body))
+			}
+
+			if res.Status >= 500 {
+				if reported, _ := c.Get(httpx.EchoContextKeyErrorReported).(bool); reported {
+					logger = logger.withoutReport()
+				}
+				logger.Error("request", fields...)
+			} else {
+				logger.Warn("request", fields...)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package log
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/levongh/profile/common/httpx"
+)
+
+const testUserID = "6f1c3e4a-2b8d-4c1e-9a7f-0d5b2e8c4a11"
+
+func TestEchoLoggingMiddleware(t *testing.T) {
+	body := map[string]string{"code": "invalid_email"}
+
+	testCases := []struct {
+		name         string
+		handler      echo.HandlerFunc
+		wantLevel    zapcore.Level
+		wantStatus   int64
+		wantError    string
+		wantResponse interface{}
+		wantReported bool
+	}{
+		{
+			name: "success",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			},
+			wantLevel:  zapcore.InfoLevel,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "client error",
+			handler: func(c echo.Context) error {
+				return httpx.JSONErr(c, errors.New("mail: no address"), http.StatusBadRequest, body)
+			},
+			wantLevel:    zapcore.WarnLevel,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "mail: no address",
+			wantResponse: body,
+		},
+		{
+			name: "server error",
+			handler: func(c echo.Context) error {
+				return httpx.JSONErr(c, errors.New("pq: connection refused"), http.StatusInternalServerError, body)
+			},
+			wantLevel:    zapcore.ErrorLevel,
+			wantStatus:   http.StatusInternalServerError,
+			wantError:    "pq: connection refused",
+			wantResponse: body,
+		},
+		{
+			name: "returned error reported by error handler",
+			handler: func(c echo.Context) error {
+				return errors.New("pq: connection refused")
+			},
+			wantLevel:    zapcore.ErrorLevel,
+			wantStatus:   http.StatusInternalServerError,
+			wantError:    "pq: connection refused",
+			wantReported: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			e := echo.New()
+			handled := 0
+			errorHandler := httpx.NewHTTPErrorHandler(func(echo.Context, error) {})
+			e.HTTPErrorHandler = func(err error, c echo.Context) {
+				handled++
+				errorHandler(err, c)
+			}
+			e.Use(EchoLoggingMiddleware(newLogger(zap.New(core)), nil))
+			e.GET("/profiles/:id", func(c echo.Context) error {
+				c.Set(httpx.ContextKeyUserID.String(), testUserID)
+				return tc.handler(c)
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/profiles/42", nil))
+
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			entry := entries[0]
+			fields := entry.ContextMap()
+
+			assert.Equal(t, tc.wantLevel, entry.Level)
+			assert.Equal(t, tc.wantStatus, fields["status"])
+			assert.Equal(t, int64(rec.Code), fields["status"])
+			assert.Equal(t, "/profiles/:id", fields["route"])
+			assert.Equal(t, "/profiles/42", fields["uri"])
+			assert.Equal(t, testUserID, fields["user_id"])
+
+			if tc.wantError != "" {
+				assert.Equal(t, tc.wantError, fields[errorKeyName])
+			} else {
+				assert.NotContains(t, fields, errorKeyName)
+			}
+			if tc.wantResponse != nil {
+				assert.Equal(t, tc.wantResponse, fields["response"])
+			} else {
+				assert.NotContains(t, fields, "response")
+			}
+			if tc.wantReported {
+				assert.Equal(t, noReportLoggerName, entry.LoggerName, "reported error isn't sent to Sentry again")
+				assert.Equal(t, 1, handled, "the error is handled once")
+			} else {
+				assert.Empty(t, entry.LoggerName)
+				assert.Zero(t, handled)
+			}
+		})
+	}
+}
+
+func TestEchoLoggingMiddlewareSkip(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	e := echo.New()
+	e.Use(EchoLoggingMiddleware(newLogger(zap.New(core)), func(c echo.Context) bool {
+		return c.Path() == "/health-check"
+	}))
+	for _, path := range []string{"/health-check", "/profile"} {
+		e.GET(path, func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+	}
+
+	for _, path := range []string{"/health-check", "/profile"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/profile", entries[0].ContextMap()["route"])
+	assert.NotContains(t, entries[0].ContextMap(), "user_id", "anonymous request")
+}
@@ -15,6 +15,7 @@ const (
 	IntType
 	TimeType
 	ErrorType
+	ReflectType
 
 	modeDev = "development"
 )
@@ -37,6 +38,8 @@ func (f *Field) toZapField() zap.Field {
 		return zap.Time(f.Key, f.Value.(time.Time))
 	case ErrorType:
 		return zap.Error(f.Value.(error))
+	case ReflectType:
+		return zap.Reflect(f.Key, f.Value)
 	default:
 		return zap.Any(f.Key, f.Value)
 	}
@@ -90,6 +93,15 @@ func Any(key string, value interface{}) Field {
 	}
 }
 
+// Reflect serializes value with reflection even if it implements error or fmt.Stringer
+func Reflect(key string, value interface{}) Field {
+	return Field{
+		Key:   key,
+		Type:  ReflectType,
+		Value: value,
+	}
+}
+
 func fieldsToInterface(fields []Field) []interface{} {
 	var res = make([]interface{}, 0, len(fields))
 	for _, f := range fields {
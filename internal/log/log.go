@@ -9,7 +9,7 @@ import (
 	common "github.com/levongh/profile/common/config"
 )
 
-func NewLogger(service string, logLevel Level) (*Logger, error) {
+func NewLogger(service string, logLevel Level, opts ...Option) (*Logger, error) {
 	zapLevel := getZapLevel(logLevel)
 
 	cfg := newZapConfig(service, zapLevel)
@@ -18,7 +18,35 @@ func NewLogger(service string, logLevel Level) (*Logger, error) {
 		return nil, err
 	}
 
-	return newLogger(zl), nil
+	logger := newLogger(zl)
+	for _, opt := range opts {
+		opt(logger)
+	}
+	if logger.sentryOption.sentryDsn == "" {
+		return logger, nil
+	}
+
+	options := newSentryOptions(logger.sentryOption.sentryDsn, logger.sentryOption.environment, service)
+	options.MinLevel = zapcore.ErrorLevel
+	for key, value := range logger.sentryOption.sentryTags {
+		options.Tags[key] = value
+	}
+	sentryCore, err := newSentryCore(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init sentry core: %w", err)
+	}
+
+	logger.sentry = sentryCore
+	logger.zapLogger = zap.New(zapcore.NewTee(zl.Core(), sentryCore)).Sugar()
+	return logger, nil
+}
+
+// Flush sends buffered Sentry events, it is called before the process exits
+func (l *Logger) Flush() error {
+	if l.sentry == nil {
+		return nil
+	}
+	return l.sentry.Sync()
 }
 
 // New is deprecated, use NewLogger
@@ -83,10 +111,21 @@ func (l *Logger) Warnf(template string, args ...interface{}) {
 	l.zapLogger.Warnf(template, args...)
 }
 
+// withoutReport returns the logger which doesn't send entries to Sentry,
+// it is used for errors which are already reported
+func (l *Logger) withoutReport() *Logger {
+	return &Logger{
+		zapLogger:    l.zapLogger.Named(noReportLoggerName),
+		sentryOption: l.sentryOption,
+		sentry:       l.sentry,
+	}
+}
+
 func (l *Logger) AddField(name, value string) *Logger {
 	return &Logger{
 		zapLogger:    l.zapLogger.With(name, value),
 		sentryOption: l.sentryOption,
+		sentry:       l.sentry,
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 
 type sentryOption struct {
 	sentryDsn    string
+	environment  string
 	sentryTags   map[string]string
 	sentryFields []zapcore.Field
 }
@@ -25,9 +26,19 @@ func WithSentry(dsn string, tags map[string]string, fields ...zapcore.Field) Opt
 	}
 }
 
+// WithSentryEnvironment sets environment of Sentry events, e.g. the service mode
+func WithSentryEnvironment(env string) Option {
+	return func(logger *Logger) {
+		logger.sentryOption.environment = env
+	}
+}
+
 const (
 	serviceTag = "service"
 
+	// entries of the logger with this name are not sent to Sentry
+	noReportLoggerName = "no-report"
+
 	// sentry specific
 	prefixTagZapField = "prefixTagZapField"
 	zapFieldPrefix    = "zapfield_prefix"
@@ -97,7 +108,7 @@ func newSentryCore(options SentryOptions) (*SentryCore, error) {
 }
 
 func (s *SentryCore) Check(entry zapcore.Entry, check *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	if s.Enabled(entry.Level) {
+	if s.Enabled(entry.Level) && entry.LoggerName != noReportLoggerName {
 		return check.AddCore(entry, s)
 	}
 	return check
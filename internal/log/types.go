@@ -29,6 +29,8 @@ type Option func(*Logger)
 type Logger struct {
 	zapLogger    *zap.SugaredLogger
 	sentryOption sentryOption
+	// sentry is set if events are reported to Sentry, it is used to flush them
+	sentry *SentryCore
 }
 
 func newLogger(zap *zap.Logger) *Logger {
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is available to every template
+type Data struct {
+	Link      string
+	ExpiresIn time.Duration
+	// AntiPhishingCode is set by the user to recognize genuine emails, it is
+	// printed at the top of every email when present
+	AntiPhishingCode string
+}
+
+type Template struct {
+	subject string
+	body    *template.Template
+}
+
+var VerifyEmail = newTemplate("Confirm your email", `Hello,
+
+please confirm your email address by following the link below:
+
+{{ .Link }}
+
+The link expires in {{ .ExpiresIn }}. If you did not request it, just ignore this email.
+`)
+
+var ResetPassword = newTemplate("Reset your password", `Hello,
+
+we received a request to reset the password of your account. You can set a new password by following the link below:
+
+{{ .Link }}
+
+The link expires in {{ .ExpiresIn }}. If you did not request it, just ignore this email, your password stays the same.
+`)
+
+var PasswordChanged = newTemplate("Your password was changed", `Hello,
+
+the password of your account was just changed and all other sessions were signed out.
+
+If it wasn't you, please reset your password immediately and contact our support.
+`)
+
+const antiPhishingHeader = `{{ with .AntiPhishingCode }}Anti-phishing code: {{ . }}
+
+{{ end }}`
+
+func newTemplate(subject, body string) *Template {
+	return &Template{
+		subject: subject,
+		body:    template.Must(template.New(subject).Parse(antiPhishingHeader + body)),
+	}
+}
+
+func (t *Template) Render(to string, data Data) (Message, error) {
+	var body strings.Builder
+	if err := t.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: t.subject,
+		Body:    body.String(),
+	}, nil
+}
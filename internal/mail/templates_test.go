@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateRenderAntiPhishingCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     Data
+		contains string
+		excludes string
+	}{
+		{name: "with code", data: Data{AntiPhishingCode: "Blue42"}, contains: "Anti-phishing code: Blue42"},
+		{name: "without code", data: Data{}, excludes: "Anti-phishing code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := PasswordChanged.Render("john@example.com", tt.data)
+			require.NoError(t, err)
+
+			assert.Equal(t, "john@example.com", msg.To)
+			if tt.contains != "" {
+				assert.Contains(t, msg.Body, tt.contains)
+			}
+			if tt.excludes != "" {
+				assert.NotContains(t, msg.Body, tt.excludes)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+
+	e := echo.New()
+	handled := 0
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled++
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+	e.Use(m.Middleware)
+	e.GET("/profiles/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/profiles/1", "/profiles/2", "/profiles/missing", "/unknown/path"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/profiles/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/profiles/:id", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.duration))
+	assert.Equal(t, 2, handled, "errors are handled once")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "go_goroutines"))
+	assert.True(t, strings.Contains(rec.Body.String(), `http_requests_total{method="GET",route="/profiles/:id",status="200"} 2`))
+}
@@ -0,0 +1,134 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // postgres migrate driver
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/levongh/profile/db"
+)
+
+var ErrSchemaOutdated = errors.New("storage schema is outdated")
+
+// Status describes the schema version of the storage against embedded migrations
+type Status struct {
+	Version uint
+	Latest  uint
+	Dirty   bool
+}
+
+func (s Status) IsUpToDate() bool {
+	return !s.Dirty && s.Version == s.Latest
+}
+
+func (s Status) String() string {
+	return fmt.Sprintf("version=%d latest=%d dirty=%t", s.Version, s.Latest, s.Dirty)
+}
+
+// Migrator applies migrations embedded into the binary
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+// New opens a separate connection to the storage, it is closed by Close
+func New(dsn string) (*Migrator, error) {
+	src, err := iofs.New(db.Migrations, db.MigrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	latest, err := latestVersion(src)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init migrations: %w", err)
+	}
+
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down rolls back given number of migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("invalid number of steps: %d", steps)
+	}
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (m *Migrator) Status() (Status, error) {
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, err
+	}
+	return Status{Version: version, Latest: m.latest, Dirty: dirty}, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Ensure applies pending migrations when autoMigrate is set, otherwise it
+// fails if the storage schema does not match embedded migrations
+func Ensure(dsn string, autoMigrate bool) error {
+	m, err := New(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close() // nolint:errcheck
+
+	if autoMigrate {
+		if err := m.Up(); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if !status.IsUpToDate() {
+		return fmt.Errorf("%w: %s", ErrSchemaOutdated, status)
+	}
+	return nil
+}
+
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("no migrations found: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
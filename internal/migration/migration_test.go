@@ -0,0 +1,61 @@
+package migration
+
+import (
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/db"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	files, err := fs.Glob(db.Migrations, path.Join(db.MigrationsDir, "*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	ups := make(map[string]bool)
+	downs := make(map[string]bool)
+	for _, file := range files {
+		name := path.Base(file)
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			ups[strings.TrimSuffix(name, ".up.sql")] = true
+		case strings.HasSuffix(name, ".down.sql"):
+			downs[strings.TrimSuffix(name, ".down.sql")] = true
+		default:
+			t.Errorf("unexpected migration file %s", name)
+		}
+	}
+	assert.Equal(t, ups, downs, "every migration can be rolled back")
+
+	src, err := iofs.New(db.Migrations, db.MigrationsDir)
+	require.NoError(t, err)
+	latest, err := latestVersion(src)
+	require.NoError(t, err)
+	assert.Equal(t, strings.SplitN(path.Base(files[len(files)-1]), "_", 2)[0], strconv.FormatUint(uint64(latest), 10))
+}
+
+func TestStatusIsUpToDate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   Status
+		expected bool
+	}{
+		{name: "latest", status: Status{Version: 3, Latest: 3}, expected: true},
+		{name: "pending", status: Status{Version: 2, Latest: 3}},
+		{name: "empty storage", status: Status{Latest: 3}},
+		{name: "dirty", status: Status{Version: 3, Latest: 3, Dirty: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.status.IsUpToDate())
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/sms"
+	"github.com/levongh/profile/internal/storage"
+)
+
+var (
+	// ErrInvalidCode is returned for wrong, expired or exhausted codes,
+	// callers should not distinguish these cases for the user
+	ErrInvalidCode = errors.New("invalid otp code")
+	// ErrTooEarly is returned if a new code is requested before ResendInterval passed
+	ErrTooEarly = errors.New("otp code was sent recently")
+)
+
+type Config struct {
+	Length         int
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+	// Key is used to hash codes before storing them
+	Key []byte
+}
+
+type Service struct {
+	cfg    Config
+	codes  storage.OTPCodeRepository
+	sender sms.Sender
+	// newCode generates codes of the given length, it is replaced in tests
+	newCode func(length int) (string, error)
+}
+
+func NewService(cfg Config, codes storage.OTPCodeRepository, sender sms.Sender) *Service {
+	return &Service{
+		cfg:     cfg,
+		codes:   codes,
+		sender:  sender,
+		newCode: secret.NewNumericCode,
+	}
+}
+
+// Start generates a new code for the purpose and sends it to the phone,
+// previously sent codes for the same purpose stop working. The code is stored only
+// once it is sent, so a failed send doesn't hold back the retry for ResendInterval
+func (s *Service) Start(ctx context.Context, profileID, purpose, phone string) error {
+	active, err := s.codes.GetActive(ctx, profileID, purpose)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	if active != nil && time.Since(active.CreatedAt) < s.cfg.ResendInterval {
+		return ErrTooEarly
+	}
+
+	code, err := s.newCode(s.cfg.Length)
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(s.cfg.TTL.Minutes()))
+	if err := s.sender.Send(ctx, phone, text); err != nil {
+		return err
+	}
+
+	return s.codes.Create(ctx, &storage.OTPCode{
+		ProfileID:   profileID,
+		Purpose:     purpose,
+		Destination: phone,
+		CodeHash:    s.hash(purpose, phone, code),
+		ExpiresAt:   time.Now().Add(s.cfg.TTL),
+	})
+}
+
+// Verify consumes the code if it matches, every call counts as an attempt
+func (s *Service) Verify(ctx context.Context, profileID, purpose, phone, code string) error {
+	active, err := s.codes.GetActive(ctx, profileID, purpose)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.codes.IncrementAttempts(ctx, active.ID, s.cfg.MaxAttempts)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+
+	if active.Destination != phone || !secret.Equal(active.CodeHash, s.hash(purpose, phone, code)) {
+		return ErrInvalidCode
+	}
+
+	err = s.codes.Consume(ctx, active.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInvalidCode
+	}
+	return err
+}
+
+// hash binds the code to the purpose and the phone it was sent to
+func (s *Service) hash(purpose, phone, code string) string {
+	return secret.HMAC(s.cfg.Key, purpose+":"+phone+":"+code)
+}
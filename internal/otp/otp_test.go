@@ -0,0 +1,118 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	testProfileID = "f3b1f7a0-9a4b-4c55-9a7e-4c2f1b2b7a10"
+	testPhone     = "+37499123456"
+)
+
+type fakeSender struct {
+	texts []string
+	// err fails sending when set
+	err error
+}
+
+func (s *fakeSender) Send(_ context.Context, _, text string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.texts = append(s.texts, text)
+	return nil
+}
+
+var rxCode = regexp.MustCompile(`\d{6}`)
+
+func (s *fakeSender) lastCode() string {
+	return rxCode.FindString(s.texts[len(s.texts)-1])
+}
+
+func newTestService(resendInterval time.Duration) (*Service, *fakeSender) {
+	sender := &fakeSender{}
+	cfg := Config{
+		Length:         6,
+		TTL:            time.Minute,
+		MaxAttempts:    3,
+		ResendInterval: resendInterval,
+		Key:            []byte("0123456789abcdef0123456789abcdef"),
+	}
+	return NewService(cfg, storage.NewMemory().OTPCodes, sender), sender
+}
+
+func TestServiceVerify(t *testing.T) {
+	ctx := context.Background()
+	s, sender := newTestService(0)
+
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+	code := sender.lastCode()
+
+	assert.Equal(t, ErrInvalidCode, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, "+37499000000", code))
+	assert.NoError(t, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, code))
+	// single use
+	assert.Equal(t, ErrInvalidCode, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, code))
+}
+
+func TestServiceVerifyAttemptsLimit(t *testing.T) {
+	ctx := context.Background()
+	s, sender := newTestService(0)
+
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+	code := sender.lastCode()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrInvalidCode, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, "wrong"))
+	}
+	assert.Equal(t, ErrInvalidCode, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, code))
+}
+
+func TestServiceStartInvalidatesPreviousCode(t *testing.T) {
+	ctx := context.Background()
+	s, sender := newTestService(0)
+	codes := []string{"111111", "222222"}
+	s.newCode = func(int) (string, error) {
+		code := codes[0]
+		codes = codes[1:]
+		return code, nil
+	}
+
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+	first := sender.lastCode()
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+	second := sender.lastCode()
+	require.Equal(t, "111111", first)
+	require.Equal(t, "222222", second)
+
+	assert.Equal(t, ErrInvalidCode, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, first))
+	assert.NoError(t, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, second))
+}
+
+func TestServiceStartTooEarly(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(time.Hour)
+
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+	assert.Equal(t, ErrTooEarly, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+}
+
+func TestServiceStartSendFailed(t *testing.T) {
+	ctx := context.Background()
+	s, sender := newTestService(time.Hour)
+
+	sender.err = errors.New("sms gateway is down")
+	assert.Equal(t, sender.err, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone))
+
+	sender.err = nil
+	require.NoError(t, s.Start(ctx, testProfileID, storage.PurposePhoneVerification, testPhone), "the retry isn't too early")
+	assert.NoError(t, s.Verify(ctx, testProfileID, storage.PurposePhoneVerification, testPhone, sender.lastCode()))
+}
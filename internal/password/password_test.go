@@ -0,0 +1,64 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testParams = Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHasherVerify(t *testing.T) {
+	h := NewHasher(testParams)
+
+	hash, err := h.Hash("adgA4$qq")
+	require.NoError(t, err)
+	assert.Contains(t, hash, "$argon2id$v=19$m=1024,t=1,p=1$")
+
+	ok, err := h.Verify("adgA4$qq", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("adgA4$qQ", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHasherVerifyInvalidHash(t *testing.T) {
+	h := NewHasher(testParams)
+
+	testCases := []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5",
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			_, err := h.Verify("adgA4$qq", tc)
+			assert.Equal(t, ErrInvalidHash, err)
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	h := NewHasher(testParams)
+	hash, err := h.Hash("adgA4$qq")
+	require.NoError(t, err)
+
+	assert.False(t, h.NeedsRehash(hash))
+	assert.True(t, h.NeedsRehash("plain"))
+
+	upgraded := testParams
+	upgraded.Iterations++
+	assert.True(t, NewHasher(upgraded).NeedsRehash(hash))
+
+	// old hashes are still verified with their own parameters
+	ok, err := NewHasher(upgraded).Verify("adgA4$qq", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
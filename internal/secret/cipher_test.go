@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestNewCipher(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "valid", key: testKey},
+		{name: "not hex", key: strings.Repeat("z", 64), wantErr: true},
+		{name: "too short", key: testKey[:32], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCipher(tt.key)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP")
+
+	again, err := c.Encrypt([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "nonce must be random")
+
+	decrypted, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", string(decrypted))
+
+	_, err = c.Decrypt(encrypted[:len(encrypted)-4] + "AAAA")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	other, err := NewCipher(strings.Repeat("ab", KeySize))
+	require.NoError(t, err)
+	_, err = other.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
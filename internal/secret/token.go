@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const tokenLength = 32
+
+// NewToken returns random url-safe token and its hash, only the hash
+// should be stored, the token itself is given to the user
+func NewToken() (token, hash string, err error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes high entropy token for lookup in storage, it must not
+// be used for low entropy values like passwords or numeric codes
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewNumericCode returns random code of given number of digits, e.g. for OTP
+func NewNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// recoveryAlphabet omits characters which are easy to confuse (0/o, 1/l/i)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// NewRecoveryCode returns random code formatted as xxxxx-xxxxx, it has to be
+// normalized with NormalizeRecoveryCode before hashing
+func NewRecoveryCode() (string, error) {
+	const length = 10
+
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	b := make([]byte, 0, length+1)
+	for i := 0; i < length; i++ {
+		if i == length/2 {
+			b = append(b, '-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		b = append(b, recoveryAlphabet[n.Int64()])
+	}
+	return string(b), nil
+}
+
+// NormalizeRecoveryCode drops separators and case, so the code can be typed in any form
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// HMAC hashes low entropy values (e.g. OTP codes) with the server key,
+// so leaked hashes can't be brute-forced without the key
+func HMAC(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value)) // nolint:errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Equal compares hashes in constant time
+func Equal(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
@@ -0,0 +1,47 @@
+package secret
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+	require.NoError(t, err)
+
+	assert.Len(t, token, 43)
+	assert.Equal(t, HashToken(token), hash)
+	assert.NotEqual(t, token, hash)
+
+	other, _, err := NewToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestNewNumericCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := NewNumericCode(6)
+		require.NoError(t, err)
+		assert.Regexp(t, `^\d{6}$`, code)
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	assert.True(t, Equal(HMAC(key, "123456"), HMAC(key, "123456")))
+	assert.False(t, Equal(HMAC(key, "123456"), HMAC(key, "123457")))
+	assert.False(t, Equal(HMAC(key, "123456"), HMAC([]byte("other"), "123456")))
+}
+
+func TestNewRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+
+	assert.Equal(t, NormalizeRecoveryCode(code), NormalizeRecoveryCode(" "+strings.ToUpper(code)))
+	assert.Len(t, NormalizeRecoveryCode(code), 10)
+}
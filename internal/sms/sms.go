@@ -0,0 +1,131 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/levongh/profile/common/httpx"
+	"github.com/levongh/profile/internal/log"
+)
+
+const (
+	ProviderLog  = "log"
+	ProviderHTTP = "http"
+
+	httpTimeout = 10 * time.Second
+)
+
+type Sender interface {
+	// Send delivers text to the phone number in E.164 format
+	Send(ctx context.Context, to, text string) error
+}
+
+// LogSender doesn't deliver anything, only the masked recipient is logged and messages are optionally
+// appended to a file, it is meant for local development only
+type LogSender struct {
+	logger *log.Logger
+	path   string
+	mu     sync.Mutex
+}
+
+func NewLogSender(logger *log.Logger, path string) *LogSender {
+	return &LogSender{logger: logger, path: path}
+}
+
+func (s *LogSender) Send(ctx context.Context, to, text string) error {
+	s.logger.WithContext(ctx).Info("sms is not sent, log provider is used", log.String("to", MaskPhone(to)))
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint:errcheck
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), to, text)
+	return err
+}
+
+// MaskPhone hides all digits of the phone except the last two, e.g. +*********00
+func MaskPhone(phone string) string {
+	const visible = 2
+
+	out := []byte(phone)
+	for i := 0; i < len(out)-visible; i++ {
+		if out[i] >= '0' && out[i] <= '9' {
+			out[i] = '*'
+		}
+	}
+	return string(out)
+}
+
+type HTTPConfig struct {
+	URL   string
+	Token string
+	From  string
+}
+
+// HTTPSender posts messages as JSON to a provider endpoint:
+//
+//	POST <URL>
+//	Authorization: Bearer <Token>
+//	{"from": "<From>", "to": "+37499000000", "text": "..."}
+//
+// Any 2xx response is treated as accepted message.
+type HTTPSender struct {
+	cfg    HTTPConfig
+	client *http.Client
+}
+
+func NewHTTPSender(cfg HTTPConfig) *HTTPSender {
+	return &HTTPSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: httpTimeout, Transport: httpx.RequestIDTransport{}},
+	}
+}
+
+type httpMessage struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (s *HTTPSender) Send(ctx context.Context, to, text string) error {
+	body, err := json.Marshal(httpMessage{From: s.cfg.From, To: to, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(httpx.ErrMsgFailedToCreateRequest, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Token != "" {
+		req.Header.Set(httpx.HeaderAuthorization, httpx.Bearer+" "+s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return httpx.ErrHTTPRequest{Err: err}
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return httpx.NewErrHTTPResponse(s.cfg.URL, resp.StatusCode, respBody)
+	}
+	return nil
+}
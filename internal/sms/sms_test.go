@@ -0,0 +1,25 @@
+package sms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskPhone(t *testing.T) {
+	testCases := []struct {
+		phone    string
+		expected string
+	}{
+		{phone: "+37499123456", expected: "+*********56"},
+		{phone: "99123456", expected: "******56"},
+		{phone: "12", expected: "12"},
+		{phone: "", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.phone, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MaskPhone(tc.phone))
+		})
+	}
+}
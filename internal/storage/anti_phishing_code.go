@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// AntiPhishingCode is shown in emails sent by the service, so the user can tell
+// them apart from phishing ones. The code is encrypted with secret.Cipher
+type AntiPhishingCode struct {
+	ProfileID     string    `db:"profile_id"`
+	CodeEncrypted string    `db:"code_encrypted"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+type AntiPhishingCodeRepository interface {
+	// Get returns ErrNotFound if the profile has no code set
+	Get(ctx context.Context, profileID string) (*AntiPhishingCode, error)
+	// Save creates or replaces the code of the profile
+	Save(ctx context.Context, c *AntiPhishingCode) error
+	// Delete returns ErrNotFound if the profile has no code set
+	Delete(ctx context.Context, profileID string) error
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AuditPasswordChanged = "password_changed"
+	AuditPasswordReset   = "password_reset"
+
+	AuditTwoFactorEnabled  = "two_factor_enabled"
+	AuditTwoFactorDisabled = "two_factor_disabled"
+	AuditRecoveryCodeUsed  = "recovery_code_used"
+	AuditRecoveryCodesNew  = "recovery_codes_regenerated"
+
+	AuditAntiPhishingCodeSet     = "anti_phishing_code_set"
+	AuditAntiPhishingCodeRemoved = "anti_phishing_code_removed"
+
+	AuditSessionRevoked = "session_revoked"
+	AuditSignedIn       = "signed_in"
+)
+
+// AuditEntry records security relevant action made on the profile
+type AuditEntry struct {
+	ID        string    `db:"id"`
+	ProfileID string    `db:"profile_id"`
+	Action    string    `db:"action"`
+	IPAddress string    `db:"ip_address"`
+	UserAgent string    `db:"user_agent"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type AuditRepository interface {
+	Add(ctx context.Context, e *AuditEntry) error
+}
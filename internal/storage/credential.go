@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type Credential struct {
+	ProfileID    string `db:"profile_id"`
+	PasswordHash string `db:"password_hash"`
+	// PasswordChangedAt is set when the user changes the password, sessions
+	// started before it are not valid anymore
+	PasswordChangedAt *time.Time `db:"password_changed_at"`
+	CreatedAt         time.Time  `db:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at"`
+}
+
+type CredentialRepository interface {
+	// Get returns ErrNotFound if the profile has no credentials
+	Get(ctx context.Context, profileID string) (*Credential, error)
+	// Save creates or replaces credentials of the profile
+	Save(ctx context.Context, c *Credential) error
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttemptRepository counts sign in attempts by key, e.g. the login or the client IP,
+// within a fixed window
+type LoginAttemptRepository interface {
+	// Increment counts an attempt and returns the number of attempts in the current window,
+	// a new window starts once the previous one is older than window
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
+	// Reset forgets attempts of the key
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryAntiPhishingCodes struct {
+	mu    sync.RWMutex
+	codes map[string]AntiPhishingCode
+}
+
+func newMemoryAntiPhishingCodes() *memoryAntiPhishingCodes {
+	return &memoryAntiPhishingCodes{
+		codes: make(map[string]AntiPhishingCode),
+	}
+}
+
+func (r *memoryAntiPhishingCodes) Get(_ context.Context, profileID string) (*AntiPhishingCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.codes[profileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *memoryAntiPhishingCodes) Save(_ context.Context, c *AntiPhishingCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	c.CreatedAt = now
+	if existing, ok := r.codes[c.ProfileID]; ok {
+		c.CreatedAt = existing.CreatedAt
+	}
+	c.UpdatedAt = now
+
+	r.codes[c.ProfileID] = *c
+	return nil
+}
+
+func (r *memoryAntiPhishingCodes) Delete(_ context.Context, profileID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.codes[profileID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.codes, profileID)
+	return nil
+}
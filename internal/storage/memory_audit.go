@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryAudit struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func newMemoryAudit() *memoryAudit {
+	return &memoryAudit{}
+}
+
+func (r *memoryAudit) Add(_ context.Context, e *AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.ID = uuid.NewString()
+	e.CreatedAt = time.Now().UTC()
+	r.entries = append(r.entries, *e)
+	return nil
+}
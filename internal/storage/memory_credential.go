@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCredentials struct {
+	mu          sync.RWMutex
+	credentials map[string]Credential
+}
+
+func newMemoryCredentials() *memoryCredentials {
+	return &memoryCredentials{
+		credentials: make(map[string]Credential),
+	}
+}
+
+func (r *memoryCredentials) Get(_ context.Context, profileID string) (*Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.credentials[profileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *memoryCredentials) Save(_ context.Context, c *Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	c.CreatedAt = now
+	if existing, ok := r.credentials[c.ProfileID]; ok {
+		c.CreatedAt = existing.CreatedAt
+	}
+	c.UpdatedAt = now
+
+	r.credentials[c.ProfileID] = *c
+	return nil
+}
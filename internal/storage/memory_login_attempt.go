@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type loginAttempts struct {
+	attempts  int
+	startedAt time.Time
+}
+
+type memoryLoginAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]loginAttempts
+}
+
+func newMemoryLoginAttempts() *memoryLoginAttempts {
+	return &memoryLoginAttempts{
+		attempts: make(map[string]loginAttempts),
+	}
+}
+
+func (r *memoryLoginAttempts) Increment(_ context.Context, key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	a, ok := r.attempts[key]
+	if !ok || !a.startedAt.After(now.Add(-window)) {
+		a = loginAttempts{startedAt: now}
+	}
+	a.attempts++
+	r.attempts[key] = a
+	return a.attempts, nil
+}
+
+func (r *memoryLoginAttempts) Reset(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
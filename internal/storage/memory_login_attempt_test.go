@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryLoginAttempts()
+
+	for want := 1; want <= 3; want++ {
+		attempts, err := repo.Increment(ctx, "ip:10.0.0.1", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, want, attempts)
+	}
+
+	attempts, err := repo.Increment(ctx, "ip:10.0.0.2", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts, "keys are counted separately")
+
+	attempts, err = repo.Increment(ctx, "ip:10.0.0.1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts, "a new window starts once the previous one is over")
+
+	require.NoError(t, repo.Reset(ctx, "ip:10.0.0.1"))
+	attempts, err = repo.Increment(ctx, "ip:10.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
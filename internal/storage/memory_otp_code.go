@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryOTPCodes struct {
+	mu    sync.Mutex
+	codes map[string]OTPCode
+}
+
+func newMemoryOTPCodes() *memoryOTPCodes {
+	return &memoryOTPCodes{
+		codes: make(map[string]OTPCode),
+	}
+}
+
+func (r *memoryOTPCodes) Create(_ context.Context, c *OTPCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, other := range r.codes {
+		if other.ProfileID == c.ProfileID && other.Purpose == c.Purpose && isActiveOTP(&other, now) {
+			other.ExpiresAt = now
+			r.codes[id] = other
+		}
+	}
+
+	c.ID = uuid.NewString()
+	c.CreatedAt = now
+	r.codes[c.ID] = *c
+	return nil
+}
+
+func (r *memoryOTPCodes) GetActive(_ context.Context, profileID, purpose string) (*OTPCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *OTPCode
+	now := time.Now().UTC()
+	for _, c := range r.codes {
+		c := c
+		if c.ProfileID != profileID || c.Purpose != purpose || !isActiveOTP(&c, now) {
+			continue
+		}
+		if latest == nil || c.CreatedAt.After(latest.CreatedAt) {
+			latest = &c
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *memoryOTPCodes) IncrementAttempts(_ context.Context, id string, maxAttempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.codes[id]
+	if !ok || c.Attempts >= maxAttempts {
+		return ErrNotFound
+	}
+	c.Attempts++
+	r.codes[id] = c
+	return nil
+}
+
+func (r *memoryOTPCodes) Consume(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.codes[id]
+	if !ok || c.ConsumedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	c.ConsumedAt = &now
+	r.codes[id] = c
+	return nil
+}
+
+func isActiveOTP(c *OTPCode, now time.Time) bool {
+	return c.ConsumedAt == nil && c.ExpiresAt.After(now)
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryProfiles struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+}
+
+func newMemoryProfiles() *memoryProfiles {
+	return &memoryProfiles{
+		profiles: make(map[string]Profile),
+	}
+}
+
+func (r *memoryProfiles) Get(_ context.Context, id string) (*Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *memoryProfiles) FindByEmail(_ context.Context, email string) (*Profile, error) {
+	return r.find(func(p *Profile) bool {
+		return p.Email != nil && strings.EqualFold(*p.Email, email)
+	})
+}
+
+func (r *memoryProfiles) FindByPhone(_ context.Context, callingCode, phone string) (*Profile, error) {
+	return r.find(func(p *Profile) bool {
+		return p.Phone != nil && *p.Phone == phone && stringValue(p.CountryCallingCode) == callingCode
+	})
+}
+
+func (r *memoryProfiles) GetMany(_ context.Context, ids []string) ([]Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	profiles := make([]Profile, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if p, ok := r.profiles[id]; ok && !seen[id] {
+			seen[id] = true
+			profiles = append(profiles, p)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].CreatedAt.Before(profiles[j].CreatedAt)
+	})
+	return profiles, nil
+}
+
+func (r *memoryProfiles) find(match func(p *Profile) bool) (*Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.profiles {
+		p := p
+		if match(&p) {
+			return &p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryProfiles) Save(_ context.Context, p *Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, other := range r.profiles {
+		if id != p.ID && sameContacts(&other, p) {
+			return ErrAlreadyExists
+		}
+	}
+
+	now := time.Now().UTC()
+	p.CreatedAt = now
+	if existing, ok := r.profiles[p.ID]; ok {
+		p.CreatedAt = existing.CreatedAt
+	}
+	p.UpdatedAt = now
+
+	r.profiles[p.ID] = *p
+	return nil
+}
+
+func (r *memoryProfiles) Update(_ context.Context, id string, u ProfileUpdate) (*Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	u.apply(&p)
+
+	for otherID, other := range r.profiles {
+		if otherID != id && sameContacts(&other, &p) {
+			return nil, ErrAlreadyExists
+		}
+	}
+
+	p.UpdatedAt = time.Now().UTC()
+	r.profiles[id] = p
+	return &p, nil
+}
+
+func (r *memoryProfiles) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.profiles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.profiles, id)
+	return nil
+}
+
+// apply mirrors updateProfileQuery
+func (u ProfileUpdate) apply(p *Profile) {
+	setIfPresent(&p.FirstName, u.FirstName)
+	setIfPresent(&p.LastName, u.LastName)
+	setIfPresent(&p.Country, u.Country)
+	setIfPresent(&p.Locale, u.Locale)
+	if u.SetBirthdate {
+		p.Birthdate = u.Birthdate
+	}
+	if u.Email != nil {
+		p.Email = u.Email
+	}
+	if u.EmailVerifiedAt != nil {
+		p.EmailVerifiedAt = u.EmailVerifiedAt
+	}
+	if u.Phone != nil {
+		p.Phone = u.Phone
+	}
+	if u.CountryCallingCode != nil {
+		p.CountryCallingCode = u.CountryCallingCode
+	}
+	if u.PhoneVerifiedAt != nil {
+		p.PhoneVerifiedAt = u.PhoneVerifiedAt
+	}
+}
+
+func setIfPresent(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// sameContacts mirrors unique indexes of profiles table
+func sameContacts(a, b *Profile) bool {
+	if a.Email != nil && b.Email != nil && strings.EqualFold(*a.Email, *b.Email) {
+		return true
+	}
+	return a.Phone != nil && b.Phone != nil && *a.Phone == *b.Phone &&
+		stringValue(a.CountryCallingCode) == stringValue(b.CountryCallingCode)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestMemoryProfilesSave(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryProfiles()
+
+	first := &Profile{ID: "1", Email: strPtr("john@doe.com"), Phone: strPtr("5551234"), CountryCallingCode: strPtr("1")}
+	require.NoError(t, repo.Save(ctx, first))
+	assert.False(t, first.CreatedAt.IsZero())
+
+	testCases := []struct {
+		name    string
+		profile *Profile
+		err     error
+	}{
+		{name: "same email", profile: &Profile{ID: "2", Email: strPtr("JOHN@doe.com")}, err: ErrAlreadyExists},
+		{name: "same phone", profile: &Profile{ID: "2", Phone: strPtr("5551234"), CountryCallingCode: strPtr("1")}, err: ErrAlreadyExists},
+		{name: "same phone other country", profile: &Profile{ID: "2", Phone: strPtr("5551234"), CountryCallingCode: strPtr("44")}},
+		{name: "update itself", profile: &Profile{ID: "1", Email: strPtr("john@doe.com")}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, repo.Save(ctx, tc.profile))
+		})
+	}
+}
+
+func TestMemoryProfilesUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryProfiles()
+
+	birthdate := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Save(ctx, &Profile{ID: "1", Email: strPtr("john@doe.com"), FirstName: "John", LastName: "Doe", Birthdate: &birthdate}))
+	require.NoError(t, repo.Save(ctx, &Profile{ID: "2", Email: strPtr("jane@doe.com")}))
+
+	p, err := repo.Update(ctx, "1", ProfileUpdate{FirstName: strPtr("Johnny")})
+	require.NoError(t, err)
+	assert.Equal(t, "Johnny", p.FirstName)
+	assert.Equal(t, "Doe", p.LastName)
+	assert.Equal(t, &birthdate, p.Birthdate)
+
+	p, err = repo.Update(ctx, "1", ProfileUpdate{SetBirthdate: true})
+	require.NoError(t, err)
+	assert.Nil(t, p.Birthdate)
+
+	_, err = repo.Update(ctx, "1", ProfileUpdate{Email: strPtr("Jane@doe.com")})
+	assert.Equal(t, ErrAlreadyExists, err)
+	p, err = repo.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "john@doe.com", *p.Email)
+
+	_, err = repo.Update(ctx, "3", ProfileUpdate{FirstName: strPtr("Nobody")})
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMemoryProfilesDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryProfiles()
+
+	require.NoError(t, repo.Save(ctx, &Profile{ID: "1"}))
+	require.NoError(t, repo.Delete(ctx, "1"))
+
+	_, err := repo.Get(ctx, "1")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, repo.Delete(ctx, "1"))
+}
+
+func TestMemoryProfilesGetMany(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryProfiles()
+
+	require.NoError(t, repo.Save(ctx, &Profile{ID: "1"}))
+	require.NoError(t, repo.Save(ctx, &Profile{ID: "2"}))
+
+	profiles, err := repo.GetMany(ctx, []string{"2", "3", "1", "2"})
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "1", profiles[0].ID)
+	assert.Equal(t, "2", profiles[1].ID)
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRefreshTokens struct {
+	mu     sync.Mutex
+	tokens map[string]RefreshToken
+}
+
+func newMemoryRefreshTokens() *memoryRefreshTokens {
+	return &memoryRefreshTokens{
+		tokens: make(map[string]RefreshToken),
+	}
+}
+
+func (r *memoryRefreshTokens) Create(_ context.Context, t *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.tokens {
+		if other.TokenHash == t.TokenHash {
+			return ErrAlreadyExists
+		}
+	}
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now().UTC()
+	r.tokens[t.ID] = *t
+	return nil
+}
+
+func (r *memoryRefreshTokens) GetByHash(_ context.Context, hash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryRefreshTokens) MarkUsed(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil || t.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	t.UsedAt = &now
+	r.tokens[id] = t
+	return nil
+}
+
+func (r *memoryRefreshTokens) RevokeFamily(_ context.Context, familyID string) error {
+	r.revoke(func(t *RefreshToken) bool {
+		return t.FamilyID == familyID
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokens) RevokeProfile(_ context.Context, profileID, exceptSessionID string) error {
+	r.revoke(func(t *RefreshToken) bool {
+		return t.ProfileID == profileID && (t.SessionID == nil || *t.SessionID != exceptSessionID)
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokens) RevokeSession(_ context.Context, profileID, sessionID string) error {
+	r.revoke(func(t *RefreshToken) bool {
+		return t.ProfileID == profileID && t.SessionID != nil && *t.SessionID == sessionID
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokens) revoke(match func(t *RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, t := range r.tokens {
+		if t.RevokedAt == nil && match(&t) {
+			t.RevokedAt = &now
+			r.tokens[id] = t
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/levongh/profile/common/pagination"
+)
+
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{
+		sessions: make(map[string]Session),
+	}
+}
+
+func (r *memorySessions) Touch(_ context.Context, s *Session) error {
+	return r.upsert(s, false)
+}
+
+func (r *memorySessions) Start(_ context.Context, s *Session) error {
+	return r.upsert(s, true)
+}
+
+func (r *memorySessions) TouchByID(_ context.Context, s *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.sessions[s.ID]
+	if !ok || existing.ProfileID != s.ProfileID || existing.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+	existing.IPAddress = s.IPAddress
+	existing.UserAgent = s.UserAgent
+	existing.Location = s.Location
+	existing.LastSeenAt = time.Now().UTC()
+	r.sessions[s.ID] = existing
+
+	s.FirstSeenAt = existing.FirstSeenAt
+	s.LastSeenAt = existing.LastSeenAt
+	return nil
+}
+
+// upsert mirrors touchSessionQuery and startSessionQuery
+func (r *memorySessions) upsert(s *Session, restore bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	s.ID = uuid.NewString()
+	s.FirstSeenAt = now
+	for _, existing := range r.sessions {
+		if existing.ProfileID == s.ProfileID && existing.Device == s.Device {
+			if existing.RevokedAt != nil && !restore {
+				return ErrSessionRevoked
+			}
+			s.ID = existing.ID
+			if existing.RevokedAt == nil {
+				s.FirstSeenAt = existing.FirstSeenAt
+			}
+			break
+		}
+	}
+	s.LastSeenAt = now
+	s.RevokedAt = nil
+
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *memorySessions) List(_ context.Context, profileID string, page *pagination.Page) ([]Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make([]Session, 0)
+	for _, s := range r.sessions {
+		if s.ProfileID == profileID && s.RevokedAt == nil {
+			all = append(all, s)
+		}
+	}
+
+	idx := page.Slice(len(all), func(i int) (string, string) {
+		return all[i].PageKey(page.Column())
+	})
+	sessions := make([]Session, 0, len(idx))
+	for _, i := range idx {
+		sessions = append(sessions, all[i])
+	}
+	return sessions, nil
+}
+
+func (r *memorySessions) Revoke(_ context.Context, profileID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok || s.ProfileID != profileID || s.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	s.RevokedAt = &now
+	r.sessions[id] = s
+	return nil
+}
+
+func (r *memorySessions) RevokeAll(_ context.Context, profileID, exceptID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, s := range r.sessions {
+		if s.ProfileID == profileID && id != exceptID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+			r.sessions[id] = s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/common/pagination"
+)
+
+func defaultSessionsPage(t *testing.T) *pagination.Page {
+	page, res := pagination.Parse(url.Values{}, SessionsPage)
+	require.True(t, res.IsValid())
+	return page
+}
+
+func TestMemorySessions(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySessions()
+
+	phone := &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.1"}
+	require.NoError(t, repo.Touch(ctx, phone))
+	require.NoError(t, repo.Touch(ctx, &Session{ProfileID: "1", Device: "laptop", IPAddress: "10.0.0.2"}))
+	require.NoError(t, repo.Touch(ctx, &Session{ProfileID: "2", Device: "phone", IPAddress: "10.0.0.3"}))
+
+	again := &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.4"}
+	require.NoError(t, repo.Touch(ctx, again))
+	assert.Equal(t, phone.ID, again.ID, "same device must keep the session")
+	assert.Equal(t, phone.FirstSeenAt, again.FirstSeenAt)
+
+	sessions, err := repo.List(ctx, "1", defaultSessionsPage(t))
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "laptop", sessions[0].Device, "most recently signed in first")
+	assert.Equal(t, "10.0.0.4", sessions[1].IPAddress)
+
+	assert.Equal(t, ErrNotFound, repo.Revoke(ctx, "2", phone.ID), "other profile's session")
+
+	require.NoError(t, repo.RevokeAll(ctx, "1", phone.ID))
+	sessions, err = repo.List(ctx, "1", defaultSessionsPage(t))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+
+	require.NoError(t, repo.Revoke(ctx, "1", phone.ID))
+	assert.Equal(t, ErrNotFound, repo.Revoke(ctx, "1", phone.ID), "already revoked")
+	sessions, err = repo.List(ctx, "2", defaultSessionsPage(t))
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestMemorySessionsRevoked(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySessions()
+
+	phone := &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.1"}
+	require.NoError(t, repo.Touch(ctx, phone))
+	require.NoError(t, repo.Revoke(ctx, "1", phone.ID))
+
+	assert.Equal(t, ErrSessionRevoked, repo.Touch(ctx, &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.1"}))
+	assert.Equal(t, ErrSessionRevoked, repo.TouchByID(ctx, &Session{ID: phone.ID, ProfileID: "1", IPAddress: "10.0.0.1"}))
+	sessions, err := repo.List(ctx, "1", defaultSessionsPage(t))
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "revoked session must not come back")
+
+	// signing in again restores the device
+	signedIn := &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.2"}
+	require.NoError(t, repo.Start(ctx, signedIn))
+	assert.Equal(t, ErrSessionRevoked, repo.TouchByID(ctx, &Session{ID: signedIn.ID, ProfileID: "2"}), "other profile's session")
+	byID := &Session{ID: signedIn.ID, ProfileID: "1", IPAddress: "10.0.0.3"}
+	require.NoError(t, repo.TouchByID(ctx, byID))
+	assert.Equal(t, signedIn.FirstSeenAt, byID.FirstSeenAt)
+	require.NoError(t, repo.Touch(ctx, &Session{ProfileID: "1", Device: "phone", IPAddress: "10.0.0.2"}))
+	sessions, err = repo.List(ctx, "1", defaultSessionsPage(t))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Nil(t, sessions[0].RevokedAt)
+}
+
+func TestMemorySessionsPagesStable(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySessions()
+
+	touch := func(device string) {
+		time.Sleep(time.Millisecond)
+		require.NoError(t, repo.Touch(ctx, &Session{ProfileID: "1", Device: device}))
+	}
+	for _, device := range []string{"phone", "laptop", "tablet"} {
+		touch(device)
+	}
+
+	var listed []string
+	query := url.Values{"limit": {"1"}}
+	for {
+		page, res := pagination.Parse(query, SessionsPage)
+		require.True(t, res.IsValid())
+		sessions, err := repo.List(ctx, "1", page)
+		require.NoError(t, err)
+		n, cursor := page.Next(len(sessions), func(i int) (string, string) {
+			return sessions[i].PageKey(page.Column())
+		})
+		for _, s := range sessions[:n] {
+			listed = append(listed, s.Device)
+		}
+		if cursor == "" {
+			break
+		}
+
+		// the device listed last is used while the client pages through sessions
+		touch("phone")
+		query.Set(pagination.CursorParam, cursor)
+	}
+	assert.Equal(t, []string{"tablet", "laptop", "phone"}, listed)
+}
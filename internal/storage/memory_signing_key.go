@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memorySigningKeys struct {
+	mu   sync.RWMutex
+	keys []SigningKey
+}
+
+func newMemorySigningKeys() *memorySigningKeys {
+	return &memorySigningKeys{}
+}
+
+func (r *memorySigningKeys) Create(_ context.Context, k *SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k.CreatedAt = time.Now().UTC()
+	r.keys = append(r.keys, *k)
+	return nil
+}
+
+func (r *memorySigningKeys) ListLatest(_ context.Context, limit int) ([]SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := append([]SigningKey(nil), r.keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryTwoFactor struct {
+	mu      sync.Mutex
+	factors map[string]TwoFactor
+	// recoveryCodes maps profile id to code hashes and whether they are used
+	recoveryCodes map[string]map[string]bool
+}
+
+func newMemoryTwoFactor() *memoryTwoFactor {
+	return &memoryTwoFactor{
+		factors:       make(map[string]TwoFactor),
+		recoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func (r *memoryTwoFactor) Get(_ context.Context, profileID string) (*TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tf, ok := r.factors[profileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &tf, nil
+}
+
+func (r *memoryTwoFactor) Save(_ context.Context, tf *TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	tf.CreatedAt = now
+	if existing, ok := r.factors[tf.ProfileID]; ok {
+		tf.CreatedAt = existing.CreatedAt
+	}
+	tf.UpdatedAt = now
+
+	r.factors[tf.ProfileID] = *tf
+	return nil
+}
+
+func (r *memoryTwoFactor) UseStep(_ context.Context, profileID string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tf, ok := r.factors[profileID]
+	if !ok || tf.LastUsedStep >= step {
+		return ErrNotFound
+	}
+	tf.LastUsedStep = step
+	tf.UpdatedAt = time.Now().UTC()
+	r.factors[profileID] = tf
+	return nil
+}
+
+func (r *memoryTwoFactor) Delete(_ context.Context, profileID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.factors[profileID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.factors, profileID)
+	delete(r.recoveryCodes, profileID)
+	return nil
+}
+
+func (r *memoryTwoFactor) ReplaceRecoveryCodes(_ context.Context, profileID string, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = false
+	}
+	r.recoveryCodes[profileID] = codes
+	return nil
+}
+
+func (r *memoryTwoFactor) UseRecoveryCode(_ context.Context, profileID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, ok := r.recoveryCodes[profileID][hash]
+	if !ok || used {
+		return ErrNotFound
+	}
+	r.recoveryCodes[profileID][hash] = true
+	return nil
+}
+
+func (r *memoryTwoFactor) CountRecoveryCodes(_ context.Context, profileID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, used := range r.recoveryCodes[profileID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
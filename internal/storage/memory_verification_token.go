@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryVerificationTokens struct {
+	mu     sync.Mutex
+	tokens map[string]VerificationToken // by token hash
+}
+
+func newMemoryVerificationTokens() *memoryVerificationTokens {
+	return &memoryVerificationTokens{
+		tokens: make(map[string]VerificationToken),
+	}
+}
+
+func (r *memoryVerificationTokens) Create(_ context.Context, t *VerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[t.TokenHash]; ok {
+		return ErrAlreadyExists
+	}
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now().UTC()
+	r.tokens[t.TokenHash] = *t
+	return nil
+}
+
+func (r *memoryVerificationTokens) Consume(_ context.Context, purpose, tokenHash string) (*VerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[tokenHash]
+	now := time.Now().UTC()
+	if !ok || t.Purpose != purpose || t.UsedAt != nil || !t.ExpiresAt.After(now) {
+		return nil, ErrNotFound
+	}
+
+	t.UsedAt = &now
+	r.tokens[tokenHash] = t
+	return &t, nil
+}
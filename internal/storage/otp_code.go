@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PurposePhoneVerification = "phone_verification"
+)
+
+// OTPCode is a short numeric code sent to the destination (e.g. phone),
+// only HMAC of the code is stored
+type OTPCode struct {
+	ID          string     `db:"id"`
+	ProfileID   string     `db:"profile_id"`
+	Purpose     string     `db:"purpose"`
+	Destination string     `db:"destination"`
+	CodeHash    string     `db:"code_hash"`
+	Attempts    int        `db:"attempts"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	ConsumedAt  *time.Time `db:"consumed_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+type OTPCodeRepository interface {
+	// Create invalidates active codes of the profile for the same purpose
+	// and stores a new one
+	Create(ctx context.Context, c *OTPCode) error
+	// GetActive returns the latest not consumed and not expired code,
+	// ErrNotFound if there is none
+	GetActive(ctx context.Context, profileID, purpose string) (*OTPCode, error)
+	// IncrementAttempts counts a verification attempt, ErrNotFound is returned
+	// if the code already has maxAttempts attempts
+	IncrementAttempts(ctx context.Context, id string, maxAttempts int) error
+	// Consume marks the code as used, ErrNotFound is returned if it is already consumed
+	Consume(ctx context.Context, id string) error
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	selectAntiPhishingCodeQuery = `
+SELECT profile_id, code_encrypted, created_at, updated_at
+FROM anti_phishing_codes
+WHERE profile_id = $1`
+
+	upsertAntiPhishingCodeQuery = `
+INSERT INTO anti_phishing_codes (profile_id, code_encrypted)
+VALUES (:profile_id, :code_encrypted)
+ON CONFLICT (profile_id) DO UPDATE SET
+    code_encrypted = EXCLUDED.code_encrypted,
+    updated_at     = now()
+RETURNING created_at, updated_at`
+
+	deleteAntiPhishingCodeQuery = `DELETE FROM anti_phishing_codes WHERE profile_id = $1`
+)
+
+type postgresAntiPhishingCodes struct {
+	db *sqlx.DB
+}
+
+func (r *postgresAntiPhishingCodes) Get(ctx context.Context, profileID string) (*AntiPhishingCode, error) {
+	var c AntiPhishingCode
+	err := r.db.GetContext(ctx, &c, selectAntiPhishingCodeQuery, profileID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *postgresAntiPhishingCodes) Save(ctx context.Context, c *AntiPhishingCode) error {
+	rows, err := r.db.NamedQueryContext(ctx, upsertAntiPhishingCodeQuery, c)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresAntiPhishingCodes) Delete(ctx context.Context, profileID string) error {
+	return execAffectingRow(ctx, r.db, deleteAntiPhishingCodeQuery, profileID)
+}
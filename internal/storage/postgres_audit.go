@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const insertAuditEntryQuery = `
+INSERT INTO audit_log (profile_id, action, ip_address, user_agent)
+VALUES (:profile_id, :action, :ip_address, :user_agent)
+RETURNING id, created_at`
+
+type postgresAudit struct {
+	db *sqlx.DB
+}
+
+func (r *postgresAudit) Add(ctx context.Context, e *AuditEntry) error {
+	rows, err := r.db.NamedQueryContext(ctx, insertAuditEntryQuery, e)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&e.ID, &e.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
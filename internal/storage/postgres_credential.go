@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	selectCredentialQuery = `
+SELECT profile_id, password_hash, password_changed_at, created_at, updated_at
+FROM credentials
+WHERE profile_id = $1`
+
+	upsertCredentialQuery = `
+INSERT INTO credentials (profile_id, password_hash, password_changed_at)
+VALUES (:profile_id, :password_hash, :password_changed_at)
+ON CONFLICT (profile_id) DO UPDATE SET
+    password_hash       = EXCLUDED.password_hash,
+    password_changed_at = EXCLUDED.password_changed_at,
+    updated_at          = now()
+RETURNING created_at, updated_at`
+)
+
+type postgresCredentials struct {
+	db *sqlx.DB
+}
+
+func (r *postgresCredentials) Get(ctx context.Context, profileID string) (*Credential, error) {
+	var c Credential
+	err := r.db.GetContext(ctx, &c, selectCredentialQuery, profileID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *postgresCredentials) Save(ctx context.Context, c *Credential) error {
+	return saveCredential(ctx, r.db, c)
+}
+
+// saveCredential upserts credentials with db or transaction
+func saveCredential(ctx context.Context, db sqlx.ExtContext, c *Credential) error {
+	rows, err := sqlx.NamedQueryContext(ctx, db, upsertCredentialQuery, c)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
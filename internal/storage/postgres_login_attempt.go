@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	incrementLoginAttemptsQuery = `
+INSERT INTO login_attempts (key, attempts, window_started_at)
+VALUES ($1, 1, now())
+ON CONFLICT (key) DO UPDATE
+SET attempts          = CASE
+                            WHEN login_attempts.window_started_at > now() - make_interval(secs => $2)
+                                THEN login_attempts.attempts + 1
+                            ELSE 1 END,
+    window_started_at = CASE
+                            WHEN login_attempts.window_started_at > now() - make_interval(secs => $2)
+                                THEN login_attempts.window_started_at
+                            ELSE now() END
+RETURNING attempts`
+
+	deleteLoginAttemptsQuery = `DELETE FROM login_attempts WHERE key = $1`
+)
+
+type postgresLoginAttempts struct {
+	db *sqlx.DB
+}
+
+func (r *postgresLoginAttempts) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	var attempts int
+	err := r.db.QueryRowxContext(ctx, incrementLoginAttemptsQuery, key, window.Seconds()).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *postgresLoginAttempts) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, deleteLoginAttemptsQuery, key)
+	return err
+}
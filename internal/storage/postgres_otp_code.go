@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	expireOTPCodesQuery = `
+UPDATE otp_codes
+SET expires_at = now()
+WHERE profile_id = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+  AND expires_at > now()`
+
+	insertOTPCodeQuery = `
+INSERT INTO otp_codes (profile_id, purpose, destination, code_hash, expires_at)
+VALUES (:profile_id, :purpose, :destination, :code_hash, :expires_at)
+RETURNING id, created_at`
+
+	selectActiveOTPCodeQuery = `
+SELECT id, profile_id, purpose, destination, code_hash, attempts, expires_at, consumed_at, created_at
+FROM otp_codes
+WHERE profile_id = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC
+LIMIT 1`
+
+	incrementOTPAttemptsQuery = `UPDATE otp_codes SET attempts = attempts + 1 WHERE id = $1 AND attempts < $2`
+
+	consumeOTPCodeQuery = `UPDATE otp_codes SET consumed_at = now() WHERE id = $1 AND consumed_at IS NULL`
+)
+
+type postgresOTPCodes struct {
+	db *sqlx.DB
+}
+
+func (r *postgresOTPCodes) Create(ctx context.Context, c *OTPCode) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, expireOTPCodesQuery, c.ProfileID, c.Purpose); err != nil {
+		return err
+	}
+
+	query, args, err := tx.BindNamed(insertOTPCodeQuery, c)
+	if err != nil {
+		return err
+	}
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresOTPCodes) GetActive(ctx context.Context, profileID, purpose string) (*OTPCode, error) {
+	var c OTPCode
+	err := r.db.GetContext(ctx, &c, selectActiveOTPCodeQuery, profileID, purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *postgresOTPCodes) IncrementAttempts(ctx context.Context, id string, maxAttempts int) error {
+	return execAffectingRow(ctx, r.db, incrementOTPAttemptsQuery, id, maxAttempts)
+}
+
+func (r *postgresOTPCodes) Consume(ctx context.Context, id string) error {
+	return execAffectingRow(ctx, r.db, consumeOTPCodeQuery, id)
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	profileColumns = `
+id, email, phone, country_calling_code, first_name, last_name, birthdate, country, locale, rules_accepted_at,
+email_verified_at, phone_verified_at, created_at, updated_at`
+
+	selectProfileQuery        = `SELECT ` + profileColumns + ` FROM profiles WHERE id = $1`
+	selectProfileByEmailQuery = `SELECT ` + profileColumns + ` FROM profiles WHERE lower(email) = lower($1)`
+	selectProfileByPhoneQuery = `SELECT ` + profileColumns + ` FROM profiles WHERE country_calling_code = $1 AND phone = $2`
+	selectProfilesQuery       = `SELECT ` + profileColumns + ` FROM profiles WHERE id = ANY($1) ORDER BY created_at`
+
+	upsertProfileQuery = `
+INSERT INTO profiles (id, email, phone, country_calling_code, first_name, last_name, birthdate, country, locale,
+                      rules_accepted_at, email_verified_at, phone_verified_at)
+VALUES (:id, :email, :phone, :country_calling_code, :first_name, :last_name, :birthdate, :country, :locale,
+        :rules_accepted_at, :email_verified_at, :phone_verified_at)
+ON CONFLICT (id) DO UPDATE SET
+    email                = EXCLUDED.email,
+    phone                = EXCLUDED.phone,
+    country_calling_code = EXCLUDED.country_calling_code,
+    first_name           = EXCLUDED.first_name,
+    last_name            = EXCLUDED.last_name,
+    birthdate            = EXCLUDED.birthdate,
+    country              = EXCLUDED.country,
+    locale               = EXCLUDED.locale,
+    rules_accepted_at    = EXCLUDED.rules_accepted_at,
+    email_verified_at    = EXCLUDED.email_verified_at,
+    phone_verified_at    = EXCLUDED.phone_verified_at,
+    updated_at           = now()
+RETURNING created_at, updated_at`
+
+	updateProfileQuery = `
+UPDATE profiles SET
+    first_name           = COALESCE($2, first_name),
+    last_name            = COALESCE($3, last_name),
+    country              = COALESCE($4, country),
+    locale               = COALESCE($5, locale),
+    birthdate            = CASE WHEN $6::boolean THEN $7::date ELSE birthdate END,
+    email                = COALESCE($8, email),
+    email_verified_at    = COALESCE($9, email_verified_at),
+    phone                = COALESCE($10, phone),
+    country_calling_code = COALESCE($11, country_calling_code),
+    phone_verified_at    = COALESCE($12, phone_verified_at),
+    updated_at           = now()
+WHERE id = $1
+RETURNING ` + profileColumns
+
+	deleteProfileQuery = `DELETE FROM profiles WHERE id = $1`
+)
+
+type postgresProfiles struct {
+	db *sqlx.DB
+}
+
+func (r *postgresProfiles) Get(ctx context.Context, id string) (*Profile, error) {
+	return r.get(ctx, selectProfileQuery, id)
+}
+
+func (r *postgresProfiles) FindByEmail(ctx context.Context, email string) (*Profile, error) {
+	return r.get(ctx, selectProfileByEmailQuery, email)
+}
+
+func (r *postgresProfiles) FindByPhone(ctx context.Context, callingCode, phone string) (*Profile, error) {
+	return r.get(ctx, selectProfileByPhoneQuery, callingCode, phone)
+}
+
+func (r *postgresProfiles) GetMany(ctx context.Context, ids []string) ([]Profile, error) {
+	profiles := make([]Profile, 0, len(ids))
+	if err := r.db.SelectContext(ctx, &profiles, selectProfilesQuery, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *postgresProfiles) get(ctx context.Context, query string, args ...interface{}) (*Profile, error) {
+	var p Profile
+	err := r.db.GetContext(ctx, &p, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *postgresProfiles) Save(ctx context.Context, p *Profile) error {
+	return saveProfile(ctx, r.db, p)
+}
+
+func (r *postgresProfiles) Update(ctx context.Context, id string, u ProfileUpdate) (*Profile, error) {
+	p, err := r.get(ctx, updateProfileQuery, id, u.FirstName, u.LastName, u.Country, u.Locale,
+		u.SetBirthdate, u.Birthdate, u.Email, u.EmailVerifiedAt, u.Phone, u.CountryCallingCode, u.PhoneVerifiedAt)
+	if isUniqueViolation(err) {
+		return nil, ErrAlreadyExists
+	}
+	return p, err
+}
+
+func (r *postgresProfiles) Delete(ctx context.Context, id string) error {
+	return execAffectingRow(ctx, r.db, deleteProfileQuery, id)
+}
+
+// saveProfile upserts the profile with db or transaction
+func saveProfile(ctx context.Context, db sqlx.ExtContext, p *Profile) error {
+	rows, err := sqlx.NamedQueryContext(ctx, db, upsertProfileQuery, p)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&p.CreatedAt, &p.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
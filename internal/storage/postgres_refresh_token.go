@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	insertRefreshTokenQuery = `
+INSERT INTO refresh_tokens (profile_id, session_id, family_id, token_hash, expires_at)
+VALUES (:profile_id, :session_id, :family_id, :token_hash, :expires_at)
+RETURNING id, created_at`
+
+	selectRefreshTokenQuery = `
+SELECT id, profile_id, session_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+FROM refresh_tokens
+WHERE token_hash = $1`
+
+	useRefreshTokenQuery = `
+UPDATE refresh_tokens
+SET used_at = now()
+WHERE id = $1
+  AND used_at IS NULL
+  AND revoked_at IS NULL`
+
+	revokeRefreshTokenFamilyQuery = `
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE family_id = $1
+  AND revoked_at IS NULL`
+
+	revokeProfileRefreshTokensQuery = `
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE profile_id = $1
+  AND revoked_at IS NULL
+  AND (session_id IS NULL OR session_id::text <> $2)`
+
+	revokeSessionRefreshTokensQuery = `
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE profile_id = $1
+  AND session_id::text = $2
+  AND revoked_at IS NULL`
+)
+
+type postgresRefreshTokens struct {
+	db *sqlx.DB
+}
+
+func (r *postgresRefreshTokens) Create(ctx context.Context, t *RefreshToken) error {
+	rows, err := r.db.NamedQueryContext(ctx, insertRefreshTokenQuery, t)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&t.ID, &t.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresRefreshTokens) GetByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	var t RefreshToken
+	err := r.db.GetContext(ctx, &t, selectRefreshTokenQuery, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *postgresRefreshTokens) MarkUsed(ctx context.Context, id string) error {
+	return execAffectingRow(ctx, r.db, useRefreshTokenQuery, id)
+}
+
+func (r *postgresRefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, revokeRefreshTokenFamilyQuery, familyID)
+	return err
+}
+
+func (r *postgresRefreshTokens) RevokeProfile(ctx context.Context, profileID, exceptSessionID string) error {
+	_, err := r.db.ExecContext(ctx, revokeProfileRefreshTokensQuery, profileID, exceptSessionID)
+	return err
+}
+
+func (r *postgresRefreshTokens) RevokeSession(ctx context.Context, profileID, sessionID string) error {
+	_, err := r.db.ExecContext(ctx, revokeSessionRefreshTokensQuery, profileID, sessionID)
+	return err
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/levongh/profile/common/pagination"
+)
+
+const (
+	// touchSessionQuery doesn't update revoked session, so nothing is returned for it
+	touchSessionQuery = `
+INSERT INTO sessions (profile_id, device, ip_address, user_agent, location)
+VALUES (:profile_id, :device, :ip_address, :user_agent, :location)
+ON CONFLICT (profile_id, device) DO UPDATE SET
+    ip_address   = EXCLUDED.ip_address,
+    user_agent   = EXCLUDED.user_agent,
+    location     = EXCLUDED.location,
+    last_seen_at = now()
+WHERE sessions.revoked_at IS NULL
+RETURNING id, first_seen_at, last_seen_at`
+
+	startSessionQuery = `
+INSERT INTO sessions (profile_id, device, ip_address, user_agent, location)
+VALUES (:profile_id, :device, :ip_address, :user_agent, :location)
+ON CONFLICT (profile_id, device) DO UPDATE SET
+    ip_address    = EXCLUDED.ip_address,
+    user_agent    = EXCLUDED.user_agent,
+    location      = EXCLUDED.location,
+    first_seen_at = CASE WHEN sessions.revoked_at IS NULL THEN sessions.first_seen_at ELSE now() END,
+    last_seen_at  = now(),
+    revoked_at    = NULL
+RETURNING id, first_seen_at, last_seen_at`
+
+	// touchSessionByIDQuery returns nothing for revoked or unknown session
+	touchSessionByIDQuery = `
+UPDATE sessions SET
+    ip_address   = :ip_address,
+    user_agent   = :user_agent,
+    location     = :location,
+    last_seen_at = now()
+WHERE id = :id AND profile_id = :profile_id AND revoked_at IS NULL
+RETURNING id, first_seen_at, last_seen_at`
+
+	selectSessionsQuery = `
+SELECT id, profile_id, device, ip_address, user_agent, location, first_seen_at, last_seen_at, revoked_at
+FROM sessions
+WHERE profile_id = $1
+  AND revoked_at IS NULL
+  AND `
+
+	revokeSessionQuery = `UPDATE sessions SET revoked_at = now() WHERE profile_id = $1 AND id = $2 AND revoked_at IS NULL`
+
+	revokeSessionsQuery = `
+UPDATE sessions SET revoked_at = now()
+WHERE profile_id = $1 AND id::text <> $2 AND revoked_at IS NULL`
+)
+
+type postgresSessions struct {
+	db *sqlx.DB
+}
+
+func (r *postgresSessions) Touch(ctx context.Context, s *Session) error {
+	return r.upsert(ctx, touchSessionQuery, s)
+}
+
+func (r *postgresSessions) TouchByID(ctx context.Context, s *Session) error {
+	return r.upsert(ctx, touchSessionByIDQuery, s)
+}
+
+func (r *postgresSessions) Start(ctx context.Context, s *Session) error {
+	return r.upsert(ctx, startSessionQuery, s)
+}
+
+func (r *postgresSessions) upsert(ctx context.Context, query string, s *Session) error {
+	rows, err := r.db.NamedQueryContext(ctx, query, s)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrSessionRevoked
+	}
+	if err := rows.Scan(&s.ID, &s.FirstSeenAt, &s.LastSeenAt); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+func (r *postgresSessions) List(ctx context.Context, profileID string, page *pagination.Page) ([]Session, error) {
+	where, args := page.Where(2)
+	query := selectSessionsQuery + where + "\n" + page.OrderBy() + "\n" + page.Limit()
+
+	sessions := make([]Session, 0)
+	if err := r.db.SelectContext(ctx, &sessions, query, append([]interface{}{profileID}, args...)...); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *postgresSessions) Revoke(ctx context.Context, profileID, id string) error {
+	return execAffectingRow(ctx, r.db, revokeSessionQuery, profileID, id)
+}
+
+func (r *postgresSessions) RevokeAll(ctx context.Context, profileID, exceptID string) error {
+	_, err := r.db.ExecContext(ctx, revokeSessionsQuery, profileID, exceptID)
+	return err
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	insertSigningKeyQuery = `
+INSERT INTO signing_keys (id, algorithm, private_key_encrypted)
+VALUES (:id, :algorithm, :private_key_encrypted)
+RETURNING created_at`
+
+	selectSigningKeysQuery = `
+SELECT id, algorithm, private_key_encrypted, created_at
+FROM signing_keys
+ORDER BY created_at DESC
+LIMIT $1`
+)
+
+type postgresSigningKeys struct {
+	db *sqlx.DB
+}
+
+func (r *postgresSigningKeys) Create(ctx context.Context, k *SigningKey) error {
+	rows, err := r.db.NamedQueryContext(ctx, insertSigningKeyQuery, k)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&k.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresSigningKeys) ListLatest(ctx context.Context, limit int) ([]SigningKey, error) {
+	keys := make([]SigningKey, 0)
+	if err := r.db.SelectContext(ctx, &keys, selectSigningKeysQuery, limit); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	selectTwoFactorQuery = `
+SELECT profile_id, method, secret_encrypted, last_used_step, confirmed_at, created_at, updated_at
+FROM two_factor
+WHERE profile_id = $1`
+
+	upsertTwoFactorQuery = `
+INSERT INTO two_factor (profile_id, method, secret_encrypted, last_used_step, confirmed_at)
+VALUES (:profile_id, :method, :secret_encrypted, :last_used_step, :confirmed_at)
+ON CONFLICT (profile_id) DO UPDATE SET
+    method           = EXCLUDED.method,
+    secret_encrypted = EXCLUDED.secret_encrypted,
+    last_used_step   = EXCLUDED.last_used_step,
+    confirmed_at     = EXCLUDED.confirmed_at,
+    updated_at       = now()
+RETURNING created_at, updated_at`
+
+	useTwoFactorStepQuery = `
+UPDATE two_factor
+SET last_used_step = $2,
+    updated_at     = now()
+WHERE profile_id = $1
+  AND last_used_step < $2`
+
+	deleteTwoFactorQuery = `DELETE FROM two_factor WHERE profile_id = $1`
+
+	deleteRecoveryCodesQuery = `DELETE FROM recovery_codes WHERE profile_id = $1`
+
+	insertRecoveryCodeQuery = `INSERT INTO recovery_codes (profile_id, code_hash) VALUES ($1, $2)`
+
+	useRecoveryCodeQuery = `
+UPDATE recovery_codes
+SET used_at = now()
+WHERE profile_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL`
+
+	countRecoveryCodesQuery = `SELECT count(*) FROM recovery_codes WHERE profile_id = $1 AND used_at IS NULL`
+)
+
+type postgresTwoFactor struct {
+	db *sqlx.DB
+}
+
+func (r *postgresTwoFactor) Get(ctx context.Context, profileID string) (*TwoFactor, error) {
+	var tf TwoFactor
+	err := r.db.GetContext(ctx, &tf, selectTwoFactorQuery, profileID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *postgresTwoFactor) Save(ctx context.Context, tf *TwoFactor) error {
+	rows, err := r.db.NamedQueryContext(ctx, upsertTwoFactorQuery, tf)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&tf.CreatedAt, &tf.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresTwoFactor) UseStep(ctx context.Context, profileID string, step int64) error {
+	return execAffectingRow(ctx, r.db, useTwoFactorStepQuery, profileID, step)
+}
+
+func (r *postgresTwoFactor) Delete(ctx context.Context, profileID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, deleteRecoveryCodesQuery, profileID); err != nil {
+		return err
+	}
+	if err := execAffectingRow(ctx, tx, deleteTwoFactorQuery, profileID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresTwoFactor) ReplaceRecoveryCodes(ctx context.Context, profileID string, hashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, deleteRecoveryCodesQuery, profileID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, insertRecoveryCodeQuery, profileID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresTwoFactor) UseRecoveryCode(ctx context.Context, profileID, hash string) error {
+	return execAffectingRow(ctx, r.db, useRecoveryCodeQuery, profileID, hash)
+}
+
+func (r *postgresTwoFactor) CountRecoveryCodes(ctx context.Context, profileID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, countRecoveryCodesQuery, profileID)
+	return count, err
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	insertVerificationTokenQuery = `
+INSERT INTO verification_tokens (profile_id, purpose, destination, token_hash, expires_at)
+VALUES (:profile_id, :purpose, :destination, :token_hash, :expires_at)
+RETURNING id, created_at`
+
+	consumeVerificationTokenQuery = `
+UPDATE verification_tokens
+SET used_at = now()
+WHERE purpose = $1
+  AND token_hash = $2
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING id, profile_id, purpose, destination, token_hash, expires_at, used_at, created_at`
+)
+
+type postgresVerificationTokens struct {
+	db *sqlx.DB
+}
+
+func (r *postgresVerificationTokens) Create(ctx context.Context, t *VerificationToken) error {
+	rows, err := r.db.NamedQueryContext(ctx, insertVerificationTokenQuery, t)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&t.ID, &t.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *postgresVerificationTokens) Consume(ctx context.Context, purpose, tokenHash string) (*VerificationToken, error) {
+	var t VerificationToken
+	err := r.db.GetContext(ctx, &t, consumeVerificationTokenQuery, purpose, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
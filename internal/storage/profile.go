@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type Profile struct {
+	ID                 string     `db:"id"`
+	Email              *string    `db:"email"`
+	Phone              *string    `db:"phone"`
+	CountryCallingCode *string    `db:"country_calling_code"`
+	FirstName          string     `db:"first_name"`
+	LastName           string     `db:"last_name"`
+	Birthdate          *time.Time `db:"birthdate"`
+	Country            string     `db:"country"`
+	Locale             string     `db:"locale"`
+	RulesAcceptedAt    *time.Time `db:"rules_accepted_at"`
+	EmailVerifiedAt    *time.Time `db:"email_verified_at"`
+	PhoneVerifiedAt    *time.Time `db:"phone_verified_at"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+}
+
+// ProfileUpdate lists the fields Update changes, nil fields are kept as they are
+type ProfileUpdate struct {
+	FirstName *string
+	LastName  *string
+	Country   *string
+	Locale    *string
+	// Birthdate is changed only if SetBirthdate is true, nil clears it
+	Birthdate    *time.Time
+	SetBirthdate bool
+	// contacts are changed by verification flows along with the time they were verified
+	Email              *string
+	EmailVerifiedAt    *time.Time
+	Phone              *string
+	CountryCallingCode *string
+	PhoneVerifiedAt    *time.Time
+}
+
+type ProfileRepository interface {
+	// Get returns ErrNotFound if there is no profile with such id
+	Get(ctx context.Context, id string) (*Profile, error)
+	// FindByEmail looks up profile by email case-insensitively, returns ErrNotFound if there is none
+	FindByEmail(ctx context.Context, email string) (*Profile, error)
+	// FindByPhone returns ErrNotFound if there is no profile with such phone
+	FindByPhone(ctx context.Context, callingCode, phone string) (*Profile, error)
+	// GetMany returns existing profiles with given ids, missing ones are skipped
+	GetMany(ctx context.Context, ids []string) ([]Profile, error)
+	// Save creates or replaces the profile and fills its timestamps,
+	// returns ErrAlreadyExists if email or phone belongs to another profile
+	Save(ctx context.Context, p *Profile) error
+	// Update changes only the fields set in u, so concurrent updates of other fields aren't lost,
+	// and returns the updated profile. ErrNotFound is returned if there is no profile with such id,
+	// ErrAlreadyExists if email or phone belongs to another profile
+	Update(ctx context.Context, id string, u ProfileUpdate) (*Profile, error)
+	// Delete returns ErrNotFound if there is no profile with such id
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken is an opaque token exchanged for a new token pair, only its hash
+// is stored. Every refresh rotates the token within the same family
+type RefreshToken struct {
+	ID        string `db:"id"`
+	ProfileID string `db:"profile_id"`
+	// SessionID is the device session the token was issued to, if it was tracked
+	SessionID *string `db:"session_id"`
+	// FamilyID is shared by all tokens rotated from the same sign in
+	FamilyID  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t *RefreshToken) error
+	// GetByHash returns ErrNotFound if there is no such token
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// MarkUsed returns ErrNotFound if the token was already used or revoked
+	MarkUsed(ctx context.Context, id string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeProfile revokes tokens of the profile except ones of the given session, which can be empty
+	RevokeProfile(ctx context.Context, profileID, exceptSessionID string) error
+	RevokeSession(ctx context.Context, profileID, sessionID string) error
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/levongh/profile/common/pagination"
+)
+
+// ErrSessionRevoked is returned by Touch and TouchByID for the session which was signed out,
+// it stays revoked until the device signs in again
+var ErrSessionRevoked = errors.New("session revoked")
+
+// SessionsPage lists sessions, most recently signed in first by default. The default column
+// doesn't change while the device is used, so listing doesn't skip or repeat sessions between pages
+var SessionsPage = pagination.Spec{
+	Columns: map[string]string{
+		"last_seen_at":  "last_seen_at",
+		"first_seen_at": "first_seen_at",
+		"device":        "device",
+	},
+	DefaultOrder: "-first_seen_at",
+	TieBreaker:   "id",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// Session is a device the profile is signed in from, there is one session per device
+type Session struct {
+	ID          string    `db:"id"`
+	ProfileID   string    `db:"profile_id"`
+	Device      string    `db:"device"`
+	IPAddress   string    `db:"ip_address"`
+	UserAgent   string    `db:"user_agent"`
+	Location    string    `db:"location"`
+	FirstSeenAt time.Time `db:"first_seen_at"`
+	LastSeenAt  time.Time `db:"last_seen_at"`
+	// RevokedAt is set when the device is signed out, revoked sessions are not listed
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// PageKey returns the value of SessionsPage column and the tie-breaker
+func (s *Session) PageKey(column string) (value, id string) {
+	switch column {
+	case "first_seen_at":
+		return pagination.TimeValue(s.FirstSeenAt), s.ID
+	case "device":
+		return s.Device, s.ID
+	default:
+		return pagination.TimeValue(s.LastSeenAt), s.ID
+	}
+}
+
+type SessionRepository interface {
+	// Touch creates the session of the device or updates its last seen
+	// address, user agent and location, ID and FirstSeenAt are filled.
+	// Returns ErrSessionRevoked if the session of the device is revoked
+	Touch(ctx context.Context, s *Session) error
+	// TouchByID updates last seen address, user agent and location of the session s.ID of
+	// the profile, FirstSeenAt is filled. Returns ErrSessionRevoked if it is revoked or doesn't exist
+	TouchByID(ctx context.Context, s *Session) error
+	// Start is Touch on sign in, revoked session of the device is restored as a new one
+	Start(ctx context.Context, s *Session) error
+	// List returns the page of active sessions of the profile
+	List(ctx context.Context, profileID string, page *pagination.Page) ([]Session, error)
+	// Revoke returns ErrNotFound if the profile has no such active session
+	Revoke(ctx context.Context, profileID, id string) error
+	// RevokeAll revokes all sessions of the profile except the given one, which can be empty
+	RevokeAll(ctx context.Context, profileID, exceptID string) error
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SigningKey signs access tokens, a new key is published ahead of signing with it while
+// older ones are kept published until tokens signed by them expire
+type SigningKey struct {
+	// ID is published as kid of the key
+	ID                  string    `db:"id"`
+	Algorithm           string    `db:"algorithm"`
+	PrivateKeyEncrypted string    `db:"private_key_encrypted"`
+	CreatedAt           time.Time `db:"created_at"`
+}
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, k *SigningKey) error
+	// ListLatest returns up to limit newest keys, newest first
+	ListLatest(ctx context.Context, limit int) ([]SigningKey, error)
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const pqUniqueViolation = "23505"
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// ServiceStorage groups repositories of the service backed by the same storage
+type ServiceStorage struct {
+	db *sqlx.DB
+
+	Profiles           ProfileRepository
+	Credentials        CredentialRepository
+	VerificationTokens VerificationTokenRepository
+	OTPCodes           OTPCodeRepository
+	Audit              AuditRepository
+	TwoFactor          TwoFactorRepository
+	AntiPhishingCodes  AntiPhishingCodeRepository
+	Sessions           SessionRepository
+	SigningKeys        SigningKeyRepository
+	RefreshTokens      RefreshTokenRepository
+	LoginAttempts      LoginAttemptRepository
+}
+
+// NewPostgres connects to postgres, schema is expected to be migrated already
+func NewPostgres(dsn string) (*ServiceStorage, error) {
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to storage: %w", err)
+	}
+
+	return &ServiceStorage{
+		db:                 db,
+		Profiles:           &postgresProfiles{db: db},
+		Credentials:        &postgresCredentials{db: db},
+		VerificationTokens: &postgresVerificationTokens{db: db},
+		OTPCodes:           &postgresOTPCodes{db: db},
+		Audit:              &postgresAudit{db: db},
+		TwoFactor:          &postgresTwoFactor{db: db},
+		AntiPhishingCodes:  &postgresAntiPhishingCodes{db: db},
+		Sessions:           &postgresSessions{db: db},
+		SigningKeys:        &postgresSigningKeys{db: db},
+		RefreshTokens:      &postgresRefreshTokens{db: db},
+		LoginAttempts:      &postgresLoginAttempts{db: db},
+	}, nil
+}
+
+// NewMemory returns storage which keeps everything in process memory,
+// it is meant for tests and local development only
+func NewMemory() *ServiceStorage {
+	return &ServiceStorage{
+		Profiles:           newMemoryProfiles(),
+		Credentials:        newMemoryCredentials(),
+		VerificationTokens: newMemoryVerificationTokens(),
+		OTPCodes:           newMemoryOTPCodes(),
+		Audit:              newMemoryAudit(),
+		TwoFactor:          newMemoryTwoFactor(),
+		AntiPhishingCodes:  newMemoryAntiPhishingCodes(),
+		Sessions:           newMemorySessions(),
+		SigningKeys:        newMemorySigningKeys(),
+		RefreshTokens:      newMemoryRefreshTokens(),
+		LoginAttempts:      newMemoryLoginAttempts(),
+	}
+}
+
+// DB returns postgres connection pool, it is nil for in-memory storage
+func (s *ServiceStorage) DB() *sqlx.DB {
+	return s.db
+}
+
+// Ping checks the connection to postgres, in-memory storage is always available
+func (s *ServiceStorage) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.PingContext(ctx)
+}
+
+// CreateProfile saves the profile together with its credentials in one transaction,
+// so a failure never leaves a profile nobody can sign in to.
+// Returns ErrAlreadyExists if email or phone belongs to another profile
+func (s *ServiceStorage) CreateProfile(ctx context.Context, p *Profile, c *Credential) error {
+	// in-memory credentials are saved unconditionally, so there is nothing to roll back
+	if s.db == nil {
+		if err := s.Profiles.Save(ctx, p); err != nil {
+			return err
+		}
+		return s.Credentials.Save(ctx, c)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err := saveProfile(ctx, tx, p); err != nil {
+		return err
+	}
+	if err := saveCredential(ctx, tx, c); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *ServiceStorage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// execAffectingRow returns ErrNotFound if the statement didn't affect any row
+func execAffectingRow(ctx context.Context, db sqlx.ExecerContext, query string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
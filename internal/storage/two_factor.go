@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	TwoFactorMethodTOTP = "totp"
+)
+
+// TwoFactor is the second factor enrolled by the profile, it is enabled
+// only after ConfirmedAt is set
+type TwoFactor struct {
+	ProfileID string `db:"profile_id"`
+	Method    string `db:"method"`
+	// SecretEncrypted is the TOTP key encrypted with secret.Cipher
+	SecretEncrypted string `db:"secret_encrypted"`
+	// LastUsedStep is the time step of the last accepted code, codes can't be reused
+	LastUsedStep int64      `db:"last_used_step"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+}
+
+func (tf *TwoFactor) Enabled() bool {
+	return tf.ConfirmedAt != nil
+}
+
+type TwoFactorRepository interface {
+	// Get returns ErrNotFound if the profile has no second factor
+	Get(ctx context.Context, profileID string) (*TwoFactor, error)
+	// Save creates or replaces the second factor of the profile
+	Save(ctx context.Context, tf *TwoFactor) error
+	// UseStep moves LastUsedStep forward, ErrNotFound is returned if the step
+	// was already used
+	UseStep(ctx context.Context, profileID string, step int64) error
+	// Delete removes the second factor together with its recovery codes
+	Delete(ctx context.Context, profileID string) error
+
+	// ReplaceRecoveryCodes invalidates existing recovery codes and stores hashes of new ones
+	ReplaceRecoveryCodes(ctx context.Context, profileID string, hashes []string) error
+	// UseRecoveryCode marks the code as used, ErrNotFound is returned if there is
+	// no such unused code
+	UseRecoveryCode(ctx context.Context, profileID, hash string) error
+	// CountRecoveryCodes returns the number of unused recovery codes
+	CountRecoveryCodes(ctx context.Context, profileID string) (int, error)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+// VerificationToken is a single-use token sent to the destination (e.g. email)
+// to prove the user owns it, only hash of the token is stored
+type VerificationToken struct {
+	ID          string     `db:"id"`
+	ProfileID   string     `db:"profile_id"`
+	Purpose     string     `db:"purpose"`
+	Destination string     `db:"destination"`
+	TokenHash   string     `db:"token_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedAt      *time.Time `db:"used_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+type VerificationTokenRepository interface {
+	Create(ctx context.Context, t *VerificationToken) error
+	// Consume marks the token as used and returns it, ErrNotFound is returned
+	// if the token is unknown, already used or expired
+	Consume(ctx context.Context, purpose, tokenHash string) (*VerificationToken, error)
+}
@@ -0,0 +1,212 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	// Algorithm is used to sign access tokens
+	Algorithm = "ES256"
+
+	// JWKSMaxAge is how long clients, e.g. the API gateway, may cache JWKS
+	JWKSMaxAge = 5 * time.Minute
+
+	// keyCacheTTL limits how long instances may not know a key another instance generated
+	keyCacheTTL = time.Minute
+	// publishAhead is the age of a new key it starts signing at, by then every instance
+	// and every JWKS cache knows the key, so tokens signed by it are accepted everywhere
+	publishAhead = JWKSMaxAge + keyCacheTTL
+	// maxLoadedKeys covers the next key, the signing one, the previous one with unexpired
+	// tokens and keys generated concurrently by other instances
+	maxLoadedKeys = 5
+	// minReloadInterval limits reloads of keys forced by tokens with unknown kid
+	minReloadInterval = 5 * time.Second
+)
+
+type signingKey struct {
+	id        string
+	private   *ecdsa.PrivateKey
+	createdAt time.Time
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS is served at /.well-known/jwks.json for offline verification of access tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// signingKeys returns published keys, newest first. Once the newest key is older than
+// KeyRotation the next one is generated, it is published publishAhead before signing with it
+func (s *Service) signingKeys(ctx context.Context) ([]signingKey, error) {
+	return s.loadKeys(ctx, false)
+}
+
+// reloadKeys bypasses the cache, e.g. for a token signed by the key generated by another instance
+func (s *Service) reloadKeys(ctx context.Context) ([]signingKey, error) {
+	return s.loadKeys(ctx, true)
+}
+
+func (s *Service) loadKeys(ctx context.Context, force bool) ([]signingKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	fresh := now.Sub(s.cachedAt) < keyCacheTTL
+	if force {
+		fresh = now.Sub(s.cachedAt) < minReloadInterval
+	}
+	if fresh && len(s.cache) > 0 && !s.rotationDue(s.cache[0], now) {
+		return s.published(s.cache, now), nil
+	}
+
+	stored, err := s.keys.ListLatest(ctx, maxLoadedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]signingKey, 0, len(stored)+1)
+	for i := range stored {
+		k, err := s.decryptKey(&stored[i])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 || s.rotationDue(keys[0], now) {
+		k, err := s.generateKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys = append([]signingKey{k}, keys...)
+	}
+
+	s.cache = keys
+	s.cachedAt = now
+	return s.published(keys, now), nil
+}
+
+func (s *Service) rotationDue(k signingKey, now time.Time) bool {
+	return now.Sub(k.createdAt) >= s.cfg.KeyRotation
+}
+
+// published drops keys tokens signed by which have expired: a key stops signing
+// once the next one is publishAhead old, its tokens expire AccessTTL later
+func (s *Service) published(keys []signingKey, now time.Time) []signingKey {
+	i := signingKeyIndex(keys, now)
+	for ; i+1 < len(keys); i++ {
+		if now.After(keys[i].createdAt.Add(publishAhead + s.cfg.AccessTTL)) {
+			break
+		}
+	}
+	return keys[:i+1]
+}
+
+// signingKeyIndex points to the newest key published at least publishAhead ago,
+// the oldest key is used if there is none, e.g. when the very first key is generated
+func signingKeyIndex(keys []signingKey, now time.Time) int {
+	for i, k := range keys {
+		if now.Sub(k.createdAt) >= publishAhead {
+			return i
+		}
+	}
+	return len(keys) - 1
+}
+
+func findKey(keys []signingKey, id string) (signingKey, bool) {
+	for _, k := range keys {
+		if k.id == id {
+			return k, true
+		}
+	}
+	return signingKey{}, false
+}
+
+func (s *Service) generateKey(ctx context.Context) (signingKey, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		return signingKey{}, err
+	}
+	encrypted, err := s.cipher.Encrypt(der)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	stored := &storage.SigningKey{
+		ID:                  uuid.NewString(),
+		Algorithm:           Algorithm,
+		PrivateKeyEncrypted: encrypted,
+	}
+	if err := s.keys.Create(ctx, stored); err != nil {
+		return signingKey{}, err
+	}
+
+	return signingKey{id: stored.ID, private: private, createdAt: stored.CreatedAt}, nil
+}
+
+func (s *Service) decryptKey(stored *storage.SigningKey) (signingKey, error) {
+	der, err := s.cipher.Decrypt(stored.PrivateKeyEncrypted)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("failed to decrypt signing key %s: %w", stored.ID, err)
+	}
+	private, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("failed to parse signing key %s: %w", stored.ID, err)
+	}
+	return signingKey{id: stored.ID, private: private, createdAt: stored.CreatedAt}, nil
+}
+
+// JWKS returns public parts of all published keys
+func (s *Service) JWKS(ctx context.Context) (*JWKS, error) {
+	keys, err := s.signingKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, k := range keys {
+		params := k.private.Curve.Params()
+		out.Keys = append(out.Keys, JWK{
+			KeyType:   "EC",
+			Curve:     params.Name,
+			X:         encodeCoordinate(k.private.X, params.BitSize),
+			Y:         encodeCoordinate(k.private.Y, params.BitSize),
+			KeyID:     k.id,
+			Algorithm: Algorithm,
+			Use:       "sig",
+		})
+	}
+	return out, nil
+}
+
+// encodeCoordinate pads the coordinate to the curve size as required by RFC 7518
+func encodeCoordinate(n *big.Int, bitSize int) string {
+	b := make([]byte, (bitSize+7)/8)
+	return base64.RawURLEncoding.EncodeToString(n.FillBytes(b))
+}
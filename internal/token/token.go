@@ -0,0 +1,261 @@
+// Package token issues signed access tokens and opaque refresh tokens. Refresh
+// tokens are rotated on every use, reusing an old one revokes its whole family
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned if already rotated token is presented again,
+	// the whole family is revoked as the token has likely leaked
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrInvalidAccessToken is returned by Parse for malformed, expired or foreign tokens
+	ErrInvalidAccessToken = errors.New("invalid access token")
+)
+
+type Config struct {
+	// Issuer is set as iss claim and checked by Parse
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// KeyRotation is the age of the signing key after which a new one is generated
+	KeyRotation time.Duration
+}
+
+// Claims of access tokens
+type Claims struct {
+	jwt.RegisteredClaims
+	// SessionID is the device session the token was issued to
+	SessionID string `json:"sid,omitempty"`
+	// Locale is the locale of the profile when the token was issued, error messages are rendered in it
+	Locale string `json:"locale,omitempty"`
+}
+
+// Pair is returned on sign in and every refresh
+type Pair struct {
+	ProfileID    string
+	AccessToken  string
+	ExpiresIn    time.Duration
+	RefreshToken string
+}
+
+type Service struct {
+	cfg      Config
+	cipher   *secret.Cipher
+	keys     storage.SigningKeyRepository
+	tokens   storage.RefreshTokenRepository
+	profiles storage.ProfileRepository
+
+	mu       sync.Mutex
+	cache    []signingKey
+	cachedAt time.Time
+}
+
+func NewService(cfg Config, cipher *secret.Cipher, keys storage.SigningKeyRepository, tokens storage.RefreshTokenRepository,
+	profiles storage.ProfileRepository) *Service {
+	return &Service{
+		cfg:      cfg,
+		cipher:   cipher,
+		keys:     keys,
+		tokens:   tokens,
+		profiles: profiles,
+	}
+}
+
+// Issue starts a new token family, sessionID is optional
+func (s *Service) Issue(ctx context.Context, profileID, sessionID string) (*Pair, error) {
+	return s.issue(ctx, profileID, sessionID, uuid.NewString())
+}
+
+// Refresh rotates the refresh token and issues a new pair in the same family
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Pair, error) {
+	t, err := s.tokens.GetByHash(ctx, secret.HashToken(refreshToken))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.RevokedAt != nil:
+		return nil, ErrInvalidRefreshToken
+	case t.UsedAt != nil:
+		return nil, s.reused(ctx, t)
+	case time.Now().After(t.ExpiresAt):
+		return nil, ErrInvalidRefreshToken
+	}
+
+	err = s.tokens.MarkUsed(ctx, t.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		// rotated concurrently by another request with the same token
+		return nil, s.reused(ctx, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionID string
+	if t.SessionID != nil {
+		sessionID = *t.SessionID
+	}
+	return s.issue(ctx, t.ProfileID, sessionID, t.FamilyID)
+}
+
+func (s *Service) reused(ctx context.Context, t *storage.RefreshToken) error {
+	if err := s.tokens.RevokeFamily(ctx, t.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// Revoke signs out the family of the token, unknown tokens are ignored
+func (s *Service) Revoke(ctx context.Context, refreshToken string) error {
+	t, err := s.tokens.GetByHash(ctx, secret.HashToken(refreshToken))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, t.FamilyID)
+}
+
+// RevokeProfile revokes refresh tokens of the profile except ones of the given session
+func (s *Service) RevokeProfile(ctx context.Context, profileID, exceptSessionID string) error {
+	return s.tokens.RevokeProfile(ctx, profileID, exceptSessionID)
+}
+
+func (s *Service) RevokeSession(ctx context.Context, profileID, sessionID string) error {
+	return s.tokens.RevokeSession(ctx, profileID, sessionID)
+}
+
+// Parse verifies access token signed by one of the published keys
+func (s *Service) Parse(ctx context.Context, accessToken string) (*Claims, error) {
+	keys, err := s.signingKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		claims    Claims
+		reloadErr error
+	)
+	_, err = jwt.ParseWithClaims(accessToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if k, ok := findKey(keys, kid); ok {
+			return &k.private.PublicKey, nil
+		}
+		// the key may be generated by another instance after the keys were cached
+		keys, reloadErr = s.reloadKeys(ctx)
+		if reloadErr != nil {
+			return nil, reloadErr
+		}
+		if k, ok := findKey(keys, kid); ok {
+			return &k.private.PublicKey, nil
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}, jwt.WithValidMethods([]string{Algorithm}))
+	if reloadErr != nil {
+		return nil, reloadErr
+	}
+	if err != nil || !claims.VerifyIssuer(s.cfg.Issuer, true) {
+		return nil, ErrInvalidAccessToken
+	}
+	return &claims, nil
+}
+
+// VerifyAccessToken returns profile id the access token was issued to
+func (s *Service) VerifyAccessToken(ctx context.Context, accessToken string) (string, error) {
+	claims, err := s.Parse(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+func (s *Service) issue(ctx context.Context, profileID, sessionID, familyID string) (*Pair, error) {
+	access, err := s.sign(ctx, profileID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, hash, err := secret.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	t := &storage.RefreshToken{
+		ProfileID: profileID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+	}
+	if sessionID != "" {
+		t.SessionID = &sessionID
+	}
+	if err := s.tokens.Create(ctx, t); err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		ProfileID:    profileID,
+		AccessToken:  access,
+		ExpiresIn:    s.cfg.AccessTTL,
+		RefreshToken: refresh,
+	}, nil
+}
+
+func (s *Service) sign(ctx context.Context, profileID, sessionID string) (string, error) {
+	keys, err := s.signingKeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	locale, err := s.profileLocale(ctx, profileID)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	key := keys[signingKeyIndex(keys, now)]
+
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.cfg.Issuer,
+			Subject:   profileID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTTL)),
+		},
+		SessionID: sessionID,
+		Locale:    locale,
+	})
+	t.Header["kid"] = key.id
+
+	return t.SignedString(key.private)
+}
+
+// profileLocale is looked up once per issued token, so it isn't loaded for every error response,
+// the token carries the old locale until it is refreshed
+func (s *Service) profileLocale(ctx context.Context, profileID string) (string, error) {
+	p, err := s.profiles.Get(ctx, profileID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return p.Locale, nil
+}
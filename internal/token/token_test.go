@@ -0,0 +1,263 @@
+package token
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/levongh/profile/internal/secret"
+	"github.com/levongh/profile/internal/storage"
+)
+
+const (
+	testProfileID = "f3b1f7a0-9a4b-4c55-9a7e-4c2f1b2b7a10"
+	testSessionID = "8c0d7f1e-1b7a-4a53-8f0e-2f4f6a1c9b22"
+	testKey       = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+)
+
+func newTestService(t *testing.T, rotation time.Duration) *Service {
+	cipher, err := secret.NewCipher(testKey)
+	require.NoError(t, err)
+
+	ss := storage.NewMemory()
+	return NewService(Config{
+		Issuer:      "http://localhost:8030",
+		AccessTTL:   time.Minute,
+		RefreshTTL:  time.Hour,
+		KeyRotation: rotation,
+	}, cipher, ss.SigningKeys, ss.RefreshTokens, ss.Profiles)
+}
+
+func TestIssueAndParse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, time.Hour)
+
+	pair, err := s.Issue(ctx, testProfileID, testSessionID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, time.Minute, pair.ExpiresIn)
+
+	claims, err := s.Parse(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, testProfileID, claims.Subject)
+	assert.Equal(t, testSessionID, claims.SessionID)
+
+	_, err = s.Parse(ctx, pair.AccessToken+"x")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	other := newTestService(t, time.Hour)
+	_, err = other.Parse(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken, "signed by unknown key")
+}
+
+func TestIssueLocale(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, time.Hour)
+	require.NoError(t, s.profiles.Save(ctx, &storage.Profile{ID: testProfileID, Locale: "ru"}))
+
+	pair, err := s.Issue(ctx, testProfileID, "")
+	require.NoError(t, err)
+	claims, err := s.Parse(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "ru", claims.Locale)
+}
+
+func TestRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, time.Hour)
+
+	first, err := s.Issue(ctx, testProfileID, testSessionID)
+	require.NoError(t, err)
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, testProfileID, second.ProfileID)
+
+	claims, err := s.Parse(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, testSessionID, claims.SessionID, "session is kept on refresh")
+
+	_, err = s.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, err = s.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "family must be revoked after reuse")
+
+	_, err = s.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, time.Hour)
+
+	current, err := s.Issue(ctx, testProfileID, testSessionID)
+	require.NoError(t, err)
+	other, err := s.Issue(ctx, testProfileID, "")
+	require.NoError(t, err)
+
+	require.NoError(t, s.RevokeProfile(ctx, testProfileID, testSessionID))
+
+	_, err = s.Refresh(ctx, other.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	next, err := s.Refresh(ctx, current.RefreshToken)
+	require.NoError(t, err, "tokens of the kept session stay valid")
+
+	require.NoError(t, s.Revoke(ctx, next.RefreshToken))
+	_, err = s.Refresh(ctx, next.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, 10*time.Millisecond)
+
+	before, err := s.Issue(ctx, testProfileID, "")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	after, err := s.Issue(ctx, testProfileID, "")
+	require.NoError(t, err)
+
+	jwks, err := s.JWKS(ctx)
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2, "previous key stays published")
+	assert.Equal(t, "P-256", jwks.Keys[0].Curve)
+	assert.Len(t, jwks.Keys[0].X, 43)
+	assert.NotEqual(t, jwks.Keys[0].KeyID, jwks.Keys[1].KeyID)
+
+	for _, pair := range []*Pair{before, after} {
+		_, err := s.Parse(ctx, pair.AccessToken)
+		assert.NoError(t, err)
+	}
+}
+
+// fakeSigningKeys lets tests create keys of the given age
+type fakeSigningKeys struct {
+	keys []storage.SigningKey
+	age  time.Duration
+}
+
+func (r *fakeSigningKeys) Create(_ context.Context, k *storage.SigningKey) error {
+	k.CreatedAt = time.Now().Add(-r.age)
+	r.keys = append(r.keys, *k)
+	return nil
+}
+
+func (r *fakeSigningKeys) ListLatest(_ context.Context, limit int) ([]storage.SigningKey, error) {
+	keys := append([]storage.SigningKey(nil), r.keys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+func newFakeKeysService(t *testing.T, keys *fakeSigningKeys) *Service {
+	cipher, err := secret.NewCipher(testKey)
+	require.NoError(t, err)
+
+	return NewService(Config{
+		Issuer:      "http://localhost:8030",
+		AccessTTL:   time.Minute,
+		RefreshTTL:  time.Hour,
+		KeyRotation: time.Hour,
+	}, cipher, keys, storage.NewMemory().RefreshTokens, storage.NewMemory().Profiles)
+}
+
+// addKey stores a new key created age ago
+func addKey(t *testing.T, s *Service, keys *fakeSigningKeys, age time.Duration) string {
+	keys.age = age
+	k, err := s.generateKey(context.Background())
+	require.NoError(t, err)
+	keys.age = 0
+	return k.id
+}
+
+func keyIDs(t *testing.T, s *Service) []string {
+	jwks, err := s.JWKS(context.Background())
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		ids = append(ids, k.KeyID)
+	}
+	return ids
+}
+
+func signedBy(t *testing.T, s *Service) string {
+	pair, err := s.Issue(context.Background(), testProfileID, "")
+	require.NoError(t, err)
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(pair.AccessToken, &Claims{})
+	require.NoError(t, err)
+	return parsed.Header["kid"].(string)
+}
+
+func TestKeyPublishedAhead(t *testing.T) {
+	keys := &fakeSigningKeys{}
+	s := newFakeKeysService(t, keys)
+	current := addKey(t, s, keys, time.Hour+time.Minute)
+
+	// rotation is due, the next key is published but not used yet
+	assert.Equal(t, current, signedBy(t, s))
+	published := keyIDs(t, s)
+	require.Len(t, published, 2)
+	assert.Equal(t, current, published[1])
+	next := published[0]
+
+	// other instances start signing with the next key only once it is old enough
+	other := newFakeKeysService(t, keys)
+	assert.Equal(t, current, signedBy(t, other))
+	assert.Len(t, keys.keys, 2, "published next key must not be generated again")
+
+	keys.keys[1].CreatedAt = time.Now().Add(-publishAhead)
+	other = newFakeKeysService(t, keys)
+	assert.Equal(t, next, signedBy(t, other))
+	assert.Equal(t, []string{next, current}, keyIDs(t, other), "tokens of the previous key are not expired yet")
+}
+
+func TestKeyUnpublishedAfterTokensExpire(t *testing.T) {
+	keys := &fakeSigningKeys{}
+	s := newFakeKeysService(t, keys)
+	previous := addKey(t, s, keys, 50*time.Minute)
+	current := addKey(t, s, keys, publishAhead+time.Minute+time.Second)
+
+	assert.Equal(t, current, signedBy(t, s))
+	assert.Equal(t, []string{current}, keyIDs(t, s))
+	assert.NotEqual(t, previous, current)
+}
+
+func TestParseReloadsUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	keys := &fakeSigningKeys{}
+	s := newFakeKeysService(t, keys)
+	addKey(t, s, keys, 10*time.Minute)
+
+	// keys are cached before another instance generates a new one
+	_, err := s.signingKeys(ctx)
+	require.NoError(t, err)
+	other := newFakeKeysService(t, keys)
+	newest := addKey(t, other, keys, publishAhead)
+
+	pair, err := other.Issue(ctx, testProfileID, "")
+	require.NoError(t, err)
+	require.Equal(t, newest, signedBy(t, other))
+
+	_, err = s.Parse(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken, "reloads are limited")
+
+	s.cachedAt = time.Now().Add(-minReloadInterval)
+	_, err = s.Parse(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+}